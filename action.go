@@ -5,8 +5,9 @@ type ActionFunc[C any] func(ctx C, signal Signal) error
 
 // Action definition of entry/exit logic.
 type Action[C any] struct {
-	label  string
-	method ActionFunc[C]
+	label        string
+	method       ActionFunc[C]
+	compensation ActionFunc[C]
 }
 
 // String returns a string representation of the action.
@@ -4,13 +4,15 @@ package hsm
 type ActionBuilder[C any] interface {
 	WithLabel(label string) ActionBuilder[C]
 	WithMethod(method ActionFunc[C]) ActionBuilder[C]
+	WithCompensation(method ActionFunc[C]) ActionBuilder[C]
 	Build() *Action[C]
 }
 
 // actionBuilder private action builder.
 type actionBuilder[C any] struct {
-	label  string
-	method ActionFunc[C]
+	label        string
+	method       ActionFunc[C]
+	compensation ActionFunc[C]
 }
 
 // WithLabel defines action's label.
@@ -27,10 +29,19 @@ func (b *actionBuilder[C]) WithMethod(method ActionFunc[C]) ActionBuilder[C] {
 	return b
 }
 
+// WithCompensation defines a compensating method for this action, it is called to undo
+// the action whenever a later step of the same transition fails.
+func (b *actionBuilder[C]) WithCompensation(method ActionFunc[C]) ActionBuilder[C] {
+	b.compensation = method
+
+	return b
+}
+
 // Build returns a new action instance.
 func (b *actionBuilder[C]) Build() *Action[C] {
 	return &Action[C]{
-		label:  b.label,
-		method: b.method,
+		label:        b.label,
+		method:       b.method,
+		compensation: b.compensation,
 	}
 }
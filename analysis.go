@@ -0,0 +1,460 @@
+package hsm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Severity tells how likely a finding is to be a modelling bug.
+type Severity int
+
+// Severities of findings.
+const (
+	// SeverityWarning findings may be intended, e.g. a choice whose guards are exhaustive.
+	SeverityWarning Severity = iota
+
+	// SeverityError findings are bugs, e.g. transitions that can never be taken.
+	SeverityError
+)
+
+// String returns a human-readable representation of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// FindingKind identifies the modelling issue reported by a finding.
+type FindingKind int
+
+// Kinds of findings.
+const (
+	// FindingUnreachable reports vertices that cannot be reached from the start state.
+	FindingUnreachable FindingKind = iota
+
+	// FindingDeadEnd reports non-final vertices with no outgoing transition.
+	FindingDeadEnd
+
+	// FindingChoiceWithoutElse reports choices whose branches are all guarded.
+	FindingChoiceWithoutElse
+
+	// FindingAmbiguousTransitions reports vertices with several unguarded transitions
+	// triggered by the same signal, only the first of them ever being taken.
+	FindingAmbiguousTransitions
+
+	// FindingCompletionCycle reports vertices linked by signal-less transitions only,
+	// which may loop forever.
+	FindingCompletionCycle
+
+	// FindingEntryParentMismatch reports entry states whose parent is not the state
+	// they are the entry state of.
+	FindingEntryParentMismatch
+)
+
+// String returns a human-readable representation of the finding kind.
+func (k FindingKind) String() string {
+	switch k {
+	case FindingUnreachable:
+		return "unreachable"
+	case FindingDeadEnd:
+		return "dead end"
+	case FindingChoiceWithoutElse:
+		return "choice without else"
+	case FindingAmbiguousTransitions:
+		return "ambiguous transitions"
+	case FindingCompletionCycle:
+		return "completion cycle"
+	case FindingEntryParentMismatch:
+		return "entry parent mismatch"
+	}
+
+	return "unknown"
+}
+
+// Finding is a modelling issue reported by `Analyze`.
+type Finding struct {
+	// Kind of issue found
+	Kind FindingKind
+
+	// Severity of the issue
+	Severity Severity
+
+	// ID of the vertex the issue was found at
+	VertexID string
+
+	// Human-readable description of the issue
+	Message string
+}
+
+// String returns a human-readable representation of the finding, e.g.
+// "error: state `idle`: ...".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: state `%s`: %s", f.Severity, f.VertexID, f.Message)
+}
+
+// Analyze compiles the given builder and reports the modelling issues found in the
+// resulting definition, see `Definition.Analyze`. An error is returned if the builder
+// cannot be compiled.
+//
+// Usage:
+//
+//	findings, err := hsm.Analyze(builder)
+//	if err != nil {
+//		return err
+//	}
+//
+//	for _, finding := range findings {
+//		log.Println(finding)
+//	}
+func Analyze[C any](builder *Builder[C]) ([]Finding, error) {
+	def, err := builder.Definition()
+	if err != nil {
+		return nil, err
+	}
+
+	return def.Analyze(), nil
+}
+
+// Analyze reports the modelling issues found in this definition, which compiles but may
+// not behave as intended: vertices unreachable from the start state, non-final vertices
+// with no way out, choices without an unguarded else branch, several unguarded
+// transitions on the same signal from one vertex, cycles made only of signal-less
+// transitions, and entry states whose parent does not match. Findings are sorted by
+// vertex ID, the error state is never reported.
+func (d *Definition[C]) Analyze() []Finding {
+	var findings []Finding
+
+	reachable := d.reachable()
+
+	for _, v := range d.vertices {
+		if v == d.errorState {
+			continue
+		}
+
+		if !reachable[v] {
+			findings = append(findings, Finding{
+				Kind:     FindingUnreachable,
+				Severity: SeverityWarning,
+				VertexID: v.id,
+				Message:  fmt.Sprintf("%s cannot be reached from the start state `%s`", v.kind, d.start.id),
+			})
+		}
+
+		if deadEnd(v) {
+			findings = append(findings, Finding{
+				Kind:     FindingDeadEnd,
+				Severity: SeverityWarning,
+				VertexID: v.id,
+				Message:  fmt.Sprintf("non-final %s has no way out", v.kind),
+			})
+		}
+
+		if v.kind == VertexKindChoice && len(v.edges.list()) > 0 && elseBranch(v) == nil {
+			findings = append(findings, Finding{
+				Kind:     FindingChoiceWithoutElse,
+				Severity: SeverityWarning,
+				VertexID: v.id,
+				Message:  "choice has no unguarded else branch, the machine is stuck if no guard holds",
+			})
+		}
+
+		findings = append(findings, ambiguities(v)...)
+
+		if v.entryState != nil && v.entryState.parent != v {
+			parent := "none"
+			if v.entryState.parent != nil {
+				parent = fmt.Sprintf("`%s`", v.entryState.parent.id)
+			}
+
+			findings = append(findings, Finding{
+				Kind:     FindingEntryParentMismatch,
+				Severity: SeverityError,
+				VertexID: v.id,
+				Message:  fmt.Sprintf("entry state `%s` has parent %s instead", v.entryState.id, parent),
+			})
+		}
+	}
+
+	findings = append(findings, d.completionCycles()...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].VertexID != findings[j].VertexID {
+			return findings[i].VertexID < findings[j].VertexID
+		}
+
+		return findings[i].Kind < findings[j].Kind
+	})
+
+	return findings
+}
+
+// reachable returns the vertices that can be reached from the start state. Ancestors of
+// reached vertices are active, so their transitions are followed too, and targets are
+// entered through their entry states.
+func (d *Definition[C]) reachable() map[*Vertex[C]]bool {
+	reached := make(map[*Vertex[C]]bool)
+	queue := []*Vertex[C]{d.start}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		if v == nil || reached[v] {
+			continue
+		}
+
+		reached[v] = true
+		queue = append(queue, v.parent, v.entryState)
+
+		for _, t := range v.edges.list() {
+			if t.kind == TransitionKindNormal {
+				queue = append(queue, t.nextStatePtr)
+			}
+		}
+	}
+
+	return reached
+}
+
+// deadEnd tells whether the given vertex may be left in no way. States may be left using
+// the transitions of their ancestors, and states having an entry state are never the
+// current state.
+func deadEnd[C any](v *Vertex[C]) bool {
+	switch v.kind {
+	case VertexKindFinal, VertexKindError:
+		return false
+	case VertexKindState:
+		if v.entryState != nil {
+			return false
+		}
+
+		for ancestor := v; ancestor != nil; ancestor = ancestor.parent {
+			if leaves(ancestor) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return !leaves(v)
+}
+
+// leaves tells whether the given vertex has an outgoing non-internal transition.
+func leaves[C any](v *Vertex[C]) bool {
+	for _, t := range v.edges.list() {
+		if t.kind == TransitionKindNormal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// elseBranch returns the unguarded signal-less transition of the given vertex, if any.
+func elseBranch[C any](v *Vertex[C]) *Transition[C] {
+	for _, t := range v.edges.list() {
+		if t.matcher == nil && t.guard == nil {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// ambiguities reports the unguarded transitions of the given vertex shadowed by a
+// previous unguarded transition triggered by the same signal. Transitions using interface
+// or predicate matchers are ignored, as the signals they match are unknown.
+func ambiguities[C any](v *Vertex[C]) []Finding {
+	type key struct {
+		typ   reflect.Type
+		value Signal
+	}
+
+	var (
+		findings []Finding
+		first    = make(map[key]*Transition[C])
+	)
+
+	for _, t := range v.edges.list() {
+		if t.guard != nil {
+			continue
+		}
+
+		var k key
+
+		switch m := t.matcher.(type) {
+		case nil:
+		case *typeMatcher:
+			k.typ = m.typ
+		case *valueMatcher:
+			k.typ, k.value = m.typ, m.value
+		default:
+			continue
+		}
+
+		previous, ok := first[k]
+		if !ok {
+			first[k] = t
+			continue
+		}
+
+		signal := t.Signal()
+		if signal == "" {
+			signal = "none"
+		}
+
+		findings = append(findings, Finding{
+			Kind:     FindingAmbiguousTransitions,
+			Severity: SeverityError,
+			VertexID: v.id,
+			Message: fmt.Sprintf(
+				"unguarded transitions on signal `%s` go to `%s` and `%s`, only the first one is ever taken",
+				signal, previous.nextStateID, t.nextStateID,
+			),
+		})
+	}
+
+	return findings
+}
+
+// completionCycles reports the strongly connected components of the graph made of the
+// signal-less transitions of this definition, targets being entered through their entry
+// states. Cycles made only of unguarded transitions are errors, as they always loop.
+func (d *Definition[C]) completionCycles() []Finding {
+	var (
+		findings []Finding
+		index    = make(map[*Vertex[C]]int)
+		low      = make(map[*Vertex[C]]int)
+		onStack  = make(map[*Vertex[C]]bool)
+		stack    []*Vertex[C]
+		visit    func(v *Vertex[C])
+	)
+
+	visit = func(v *Vertex[C]) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, t := range completions(v) {
+			next := entered(t.nextStatePtr)
+
+			if _, seen := index[next]; !seen {
+				visit(next)
+
+				if low[next] < low[v] {
+					low[v] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[v] {
+				low[v] = index[next]
+			}
+		}
+
+		if low[v] != index[v] {
+			return
+		}
+
+		var component []*Vertex[C]
+
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+
+			if w == v {
+				break
+			}
+		}
+
+		if finding, ok := cycleFinding(component); ok {
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, v := range d.vertices {
+		if _, seen := index[v]; !seen {
+			visit(v)
+		}
+	}
+
+	return findings
+}
+
+// cycleFinding reports the given strongly connected component if it holds a cycle.
+func cycleFinding[C any](component []*Vertex[C]) (Finding, bool) {
+	members := make(map[*Vertex[C]]bool, len(component))
+	for _, v := range component {
+		members[v] = true
+	}
+
+	cyclic := len(component) > 1
+	guarded := false
+
+	for _, v := range component {
+		for _, t := range completions(v) {
+			if !members[entered(t.nextStatePtr)] {
+				continue
+			}
+
+			cyclic = cyclic || entered(t.nextStatePtr) == v
+			guarded = guarded || t.guard != nil
+		}
+	}
+
+	if !cyclic {
+		return Finding{}, false
+	}
+
+	ids := make([]string, 0, len(component))
+	for _, v := range component {
+		ids = append(ids, fmt.Sprintf("`%s`", v.id))
+	}
+
+	sort.Strings(ids)
+
+	finding := Finding{
+		Kind:     FindingCompletionCycle,
+		Severity: SeverityError,
+		VertexID: strings.Trim(ids[0], "`"),
+		Message:  fmt.Sprintf("states %s are linked by signal-less transitions only, which loop forever", strings.Join(ids, ", ")),
+	}
+
+	if guarded {
+		finding.Severity = SeverityWarning
+		finding.Message = fmt.Sprintf("states %s are linked by signal-less transitions only, which loop as long as their guards hold", strings.Join(ids, ", "))
+	}
+
+	return finding, true
+}
+
+// completions returns the normal signal-less transitions of the given vertex.
+func completions[C any](v *Vertex[C]) []*Transition[C] {
+	var out []*Transition[C]
+
+	for _, t := range v.edges.bySignal(nil) {
+		if t.kind == TransitionKindNormal {
+			out = append(out, t)
+		}
+	}
+
+	return out
+}
+
+// entered returns the vertex actually entered when the given vertex is targeted, walking
+// down entry states.
+func entered[C any](v *Vertex[C]) *Vertex[C] {
+	for v.entryState != nil {
+		v = v.entryState
+	}
+
+	return v
+}
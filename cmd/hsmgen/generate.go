@@ -0,0 +1,458 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/botchris/go-hsm"
+)
+
+// generatedHeader starts every generated file.
+const generatedHeader = "// Code generated by hsmgen"
+
+// generator emits the Go source of a machine definition.
+type generator struct {
+	def     *hsm.Model
+	machine string
+	buf     strings.Builder
+
+	// Go names given to states, signals and logic methods
+	stateIDs  map[string]string
+	variables map[string]string
+	signals   map[string]string
+	guards    map[string]string
+	actions   map[string]string
+}
+
+// generate returns the formatted Go source of the given definition, within the given
+// package. The source file is named after the given input file.
+func generate(def *hsm.Model, pkg, input string) ([]byte, error) {
+	g := &generator{
+		def:       def,
+		machine:   exported(def.Name),
+		stateIDs:  make(map[string]string),
+		variables: make(map[string]string),
+		signals:   make(map[string]string),
+		guards:    make(map[string]string),
+		actions:   make(map[string]string),
+	}
+
+	if g.machine == "" {
+		return nil, fmt.Errorf("machine name `%s` cannot be turned into a Go identifier", def.Name)
+	}
+
+	if err := g.name(); err != nil {
+		return nil, err
+	}
+
+	g.printf("%s from %s. DO NOT EDIT.\n\n", generatedHeader, input)
+	g.printf("package %s\n\n", pkg)
+	g.printf("import \"github.com/botchris/go-hsm\"\n\n")
+
+	g.constants()
+	g.signalTypes()
+	g.logic()
+
+	if err := g.builder(); err != nil {
+		return nil, err
+	}
+
+	out, err := format.Source([]byte(g.buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go source: %w", err)
+	}
+
+	return out, nil
+}
+
+// name gives a unique Go name to every state, signal, guard and action.
+func (g *generator) name() error {
+	taken := make(map[string]string)
+
+	claim := func(name, what string) error {
+		if previous, ok := taken[name]; ok && previous != what {
+			return fmt.Errorf("%s and %s are both named `%s` in Go", previous, what, name)
+		}
+
+		taken[name] = what
+
+		return nil
+	}
+
+	ids := append([]string{g.def.Error.ID}, g.stateIDList()...)
+
+	for _, id := range ids {
+		name := g.machine + exported(id) + "ID"
+		if err := claim(name, fmt.Sprintf("state `%s`", id)); err != nil {
+			return err
+		}
+
+		g.stateIDs[id] = name
+		g.variables[id] = g.variable(id)
+	}
+
+	actions := append(hsm.ModelActions(nil), g.def.Error.OnEntry...)
+
+	for _, v := range g.def.States {
+		actions = append(actions, v.OnEntry...)
+		actions = append(actions, v.OnExit...)
+
+		for _, t := range v.Transitions {
+			actions = append(actions, t.Effect...)
+
+			if t.Signal != "" {
+				name := exported(t.Signal)
+				if !strings.HasSuffix(name, "Signal") {
+					name += "Signal"
+				}
+
+				if err := claim(name, fmt.Sprintf("signal `%s`", t.Signal)); err != nil {
+					return err
+				}
+
+				g.signals[t.Signal] = name
+			}
+
+			if t.Guard != "" {
+				if err := g.method(g.guards, t.Guard, "guard", claim); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, action := range actions {
+		if err := g.method(g.actions, action, "action", claim); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// method names the logic method implementing the given guard or action label.
+func (g *generator) method(into map[string]string, label, kind string, claim func(name, what string) error) error {
+	name := exported(label)
+	if name == "" {
+		return fmt.Errorf("%s `%s` cannot be turned into a Go identifier", kind, label)
+	}
+
+	if err := claim("method "+name, fmt.Sprintf("%s `%s`", kind, label)); err != nil {
+		return err
+	}
+
+	into[label] = name
+
+	return nil
+}
+
+// variable returns a unique Go variable name for the vertex having the given ID.
+func (g *generator) variable(id string) string {
+	base := exported(id)
+	if base == "" {
+		base = "Vertex"
+	}
+
+	runes := []rune(base)
+	runes[0] = unicode.ToLower(runes[0])
+	base = string(runes) + "State"
+	name := base
+
+	for i := 2; g.isVariable(name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	return name
+}
+
+func (g *generator) isVariable(name string) bool {
+	for _, v := range g.variables {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// constants emits the state ID constants.
+func (g *generator) constants() {
+	g.printf("// State IDs of the %s machine.\nconst (\n", g.def.Name)
+
+	for _, id := range append([]string{g.def.Error.ID}, g.stateIDList()...) {
+		g.printf("%s = %s\n", g.stateIDs[id], strconv.Quote(id))
+	}
+
+	g.printf(")\n\n")
+}
+
+// signalTypes emits the signal types.
+func (g *generator) signalTypes() {
+	if len(g.signals) == 0 {
+		return
+	}
+
+	g.printf("// Signals of the %s machine.\ntype (\n", g.def.Name)
+
+	for _, name := range sortedValues(g.signals) {
+		g.printf("%s struct{}\n", name)
+	}
+
+	g.printf(")\n\n")
+}
+
+// logic emits the interface implemented by the user.
+func (g *generator) logic() {
+	g.printf("// %sLogic is implemented by the guards, actions and effects of the %s machine.\n", g.machine, g.def.Name)
+	g.printf("type %sLogic[C any] interface {\n", g.machine)
+
+	for _, label := range sortedKeys(g.guards) {
+		g.printf("// %s implements the `%s` guard.\n", g.guards[label], label)
+		g.printf("%s(ctx C) bool\n", g.guards[label])
+	}
+
+	for _, label := range sortedKeys(g.actions) {
+		g.printf("// %s implements the `%s` action.\n", g.actions[label], label)
+		g.printf("%s(ctx C, signal hsm.Signal) error\n", g.actions[label])
+	}
+
+	g.printf("}\n\n")
+}
+
+// builder emits the function returning the machine builder.
+func (g *generator) builder() error {
+	g.printf("// New%sBuilder returns a builder of the %s machine, running the given logic.\n", g.machine, g.def.Name)
+	g.printf("func New%sBuilder[C any](logic %sLogic[C]) *hsm.Builder[C] {\n", g.machine, g.machine)
+
+	errorState := g.variables[g.def.Error.ID]
+	g.printf("%s := hsm.NewErrorState[C]().\nWithID(%s).\n", errorState, g.stateIDs[g.def.Error.ID])
+	g.actionCall("OnEntry", g.def.Error.OnEntry)
+	g.printf("Build()\n\n")
+
+	ordered, err := g.ordered()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range ordered {
+		g.vertex(v)
+	}
+
+	g.printf("return hsm.NewBuilder[C]().\n")
+	g.printf("WithName(%s).\n", strconv.Quote(g.def.Name))
+	g.printf("StartingAt(%s).\n", g.variables[g.def.Start])
+	g.printf("WithErrorState(%s).\n", errorState)
+	g.printf("AddStates(\n")
+
+	for _, v := range g.def.States {
+		g.printf("%s,\n", g.variables[v.ID])
+	}
+
+	g.printf(")\n}\n")
+
+	return nil
+}
+
+// vertex emits the construction of the given vertex.
+func (g *generator) vertex(v *hsm.ModelVertex) {
+	constructors := map[string]string{
+		"":       "NewState",
+		"state":  "NewState",
+		"start":  "NewStart",
+		"choice": "NewChoice",
+		"entry":  "NewEntryState",
+		"final":  "NewFinalState",
+	}
+
+	g.printf("%s := hsm.%s[C]().\nWithID(%s).\n", g.variables[v.ID], constructors[v.Kind], g.stateIDs[v.ID])
+
+	if v.Parent != "" {
+		g.printf("ParentOf(%s).\n", g.variables[v.Parent])
+	}
+
+	if v.Entry != "" {
+		g.printf("WithEntryState(%s).\n", g.variables[v.Entry])
+	}
+
+	g.actionCall("OnEntry", v.OnEntry)
+	g.actionCall("OnExit", v.OnExit)
+
+	if len(v.Transitions) > 0 {
+		g.printf("AddTransitions(\n")
+
+		for _, t := range v.Transitions {
+			g.transition(t)
+		}
+
+		g.printf(").\n")
+	}
+
+	g.printf("Build()\n\n")
+}
+
+// transition emits the construction of the given transition.
+func (g *generator) transition(t *hsm.ModelTransition) {
+	if t.Kind == "internal" {
+		g.printf("hsm.NewInternalTransition[C]().\n")
+	} else {
+		g.printf("hsm.NewTransition[C]().\n")
+	}
+
+	if t.Signal != "" {
+		g.printf("When(&%s{}).\n", g.signals[t.Signal])
+	}
+
+	if t.Guard != "" {
+		g.printf("GuardedBy(hsm.NewGuard[C]().WithLabel(%s).WithMethod(logic.%s).Build()).\n", strconv.Quote(t.Guard), g.guards[t.Guard])
+	}
+
+	if len(t.Effect) > 0 {
+		g.printf("ApplyEffect(hsm.NewEffect[C]().WithLabel(%s).WithMethod(%s).Build()).\n", strconv.Quote(t.Effect.String()), g.methods(t.Effect))
+	}
+
+	if t.Kind != "internal" {
+		g.printf("GoTo(%s).\n", g.stateIDs[t.Target])
+	}
+
+	g.printf("Build(),\n")
+}
+
+// actionCall emits an `OnEntry`/`OnExit` call running the given actions, if any.
+func (g *generator) actionCall(call string, actions hsm.ModelActions) {
+	if len(actions) == 0 {
+		return
+	}
+
+	g.printf("%s(hsm.NewAction[C]().WithLabel(%s).WithMethod(%s).Build()).\n", call, strconv.Quote(actions.String()), g.methods(actions))
+}
+
+// methods returns the expression of the method running the given actions in order.
+func (g *generator) methods(actions hsm.ModelActions) string {
+	if len(actions) == 1 {
+		return "logic." + g.actions[actions[0]]
+	}
+
+	body := &strings.Builder{}
+	body.WriteString("func(ctx C, signal hsm.Signal) error {\n")
+
+	for _, action := range actions[:len(actions)-1] {
+		fmt.Fprintf(body, "if err := logic.%s(ctx, signal); err != nil {\nreturn err\n}\n\n", g.actions[action])
+	}
+
+	fmt.Fprintf(body, "return logic.%s(ctx, signal)\n}", g.actions[actions[len(actions)-1]])
+
+	return body.String()
+}
+
+// ordered returns the vertices of the definition in declaration order, except parents
+// and entry states go before the vertices referencing them, as vertices are linked by
+// pointer.
+func (g *generator) ordered() ([]*hsm.ModelVertex, error) {
+	var (
+		out      []*hsm.ModelVertex
+		done     = make(map[string]bool)
+		visiting = make(map[string]bool)
+		visit    func(v *hsm.ModelVertex) error
+	)
+
+	visit = func(v *hsm.ModelVertex) error {
+		if done[v.ID] {
+			return nil
+		}
+
+		if visiting[v.ID] {
+			return fmt.Errorf("state `%s` is nested within itself", v.ID)
+		}
+
+		visiting[v.ID] = true
+
+		for _, dependency := range []string{v.Parent, v.Entry} {
+			if dependency != "" {
+				if err := visit(g.def.Vertex(dependency)); err != nil {
+					return err
+				}
+			}
+		}
+
+		done[v.ID] = true
+		out = append(out, v)
+
+		return nil
+	}
+
+	for _, v := range g.def.States {
+		if err := visit(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// stateIDList returns the IDs of the vertices of the definition, in declaration order.
+func (g *generator) stateIDList() []string {
+	ids := make([]string, 0, len(g.def.States))
+	for _, v := range g.def.States {
+		ids = append(ids, v.ID)
+	}
+
+	return ids
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// exported returns the given label as an exported Go identifier, e.g. `door open` as
+// `DoorOpen` and `arm_time()` as `ArmTime`.
+func exported(label string) string {
+	words := strings.FieldsFunc(label, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	out := &strings.Builder{}
+
+	for _, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		out.WriteString(string(runes))
+	}
+
+	name := out.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	seen := make(map[string]bool)
+
+	for _, value := range m {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+
+	sort.Strings(values)
+
+	return values
+}
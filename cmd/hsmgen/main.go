@@ -0,0 +1,163 @@
+// Command hsmgen generates the Go wiring of a state machine from a PlantUML state diagram
+// or a JSON machine definition (see `hsm.DefinitionSchema`), so diagrams and code do not
+// drift apart.
+//
+// The generated file holds typed state ID constants, a struct type per signal, a
+// `<Machine>Logic[C]` interface with a method per guard, action and effect label, and a
+// `New<Machine>Builder[C](logic)` function returning the machine builder:
+//
+//	//go:generate go run github.com/botchris/go-hsm/cmd/hsmgen -in turnstile.puml
+//
+// Usage:
+//
+//	hsmgen -in <diagram.puml|definition.json> [-out file.go] [-package name] [-format plantuml|json] [-name machine]
+//
+// Output defaults to `<input>_hsm.go` next to the input file, and the package defaults
+// to `$GOPACKAGE` as set by `go generate`. Existing files are only overwritten if they were
+// generated by hsmgen. PlantUML diagrams are named after their
+// `HSM <name>@...` caption (as printed by `hsm.PlantUMLPrinter`), or after their file.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/botchris/go-hsm"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "hsmgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("hsmgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var (
+		in     = flags.String("in", "", "PlantUML diagram or JSON definition to read (required)")
+		out    = flags.String("out", "", "Go file to write, defaults to <input>_hsm.go")
+		pkg    = flags.String("package", os.Getenv("GOPACKAGE"), "package of the generated file")
+		format = flags.String("format", "", "input format, plantuml or json, guessed from the input extension by default")
+		name   = flags.String("name", "", "machine name, overrides the one found in the input")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *in == "" {
+		flags.Usage()
+
+		return fmt.Errorf("no input was provided")
+	}
+
+	if *pkg == "" {
+		*pkg = "main"
+	}
+
+	ext := strings.ToLower(filepath.Ext(*in))
+	base := strings.TrimSuffix(filepath.Base(*in), filepath.Ext(*in))
+
+	if *format == "" {
+		*format = "plantuml"
+		if ext == ".json" {
+			*format = "json"
+		}
+	}
+
+	if *out == "" {
+		*out = filepath.Join(filepath.Dir(*in), base+"_hsm.go")
+	}
+
+	if err := writable(*in, *out); err != nil {
+		return err
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var model *hsm.Model
+
+	switch *format {
+	case "plantuml":
+		model, err = hsm.ParsePlantUML(file)
+	case "json":
+		model, err = readJSON(file)
+	default:
+		return fmt.Errorf("unknown format `%s`", *format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	if *name != "" {
+		model.Name = *name
+	}
+
+	if model.Name == "" {
+		model.Name = base
+	}
+
+	if err := model.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	source, err := generate(model, *pkg, filepath.Base(*in))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	return os.WriteFile(*out, source, 0o644)
+}
+
+// writable ensures the given output file can be written without clobbering the input
+// or a file not generated by hsmgen.
+func writable(in, out string) error {
+	if filepath.Clean(in) == filepath.Clean(out) {
+		return fmt.Errorf("output file `%s` is the input file", out)
+	}
+
+	existing, err := os.ReadFile(out)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(existing, []byte(generatedHeader)) {
+		return fmt.Errorf("output file `%s` exists and was not generated by hsmgen", out)
+	}
+
+	return nil
+}
+
+// readJSON reads the given JSON definition, which is validated once named.
+func readJSON(r io.Reader) (*hsm.Model, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	model := &hsm.Model{}
+	if err := decoder.Decode(model); err != nil {
+		return nil, fmt.Errorf("invalid JSON definition: %w", err)
+	}
+
+	return model, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("WHEN generation fails THEN a meaningful error is returned", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			model    string
+			args     []string
+			existing string
+			err      string
+		}{
+			{
+				name: "no input",
+				err:  "no input was provided",
+			},
+			{
+				name:  "unknown format",
+				model: doorModel("closed", "", "open()"),
+				args:  []string{"-format", "yaml"},
+				err:   "unknown format `yaml`",
+			},
+			{
+				name:  "malformed definition",
+				model: `{"name": "door", "doors": []}`,
+				err:   "invalid JSON definition",
+			},
+			{
+				name:  "invalid model",
+				model: doorModel("missing", "", "open()"),
+				err:   "model $.start: state `missing` not found",
+			},
+			{
+				name:  "guard without identifier",
+				model: doorModel("closed", "?", "open()"),
+				err:   "guard `?` cannot be turned into a Go identifier",
+			},
+			{
+				name:  "action without identifier",
+				model: doorModel("closed", "", "()"),
+				err:   "action `()` cannot be turned into a Go identifier",
+			},
+			{
+				name:  "guard and action named alike",
+				model: doorModel("closed", "open", "open()"),
+				err:   "guard `open` and action `open()` are both named `method Open` in Go",
+			},
+			{
+				name: "states named alike",
+				model: `{
+					"name": "door",
+					"start": "door open",
+					"error": {"id": "error"},
+					"states": [{"id": "door open"}, {"id": "door_open"}]
+				}`,
+				err: "state `door open` and state `door_open` are both named `DoorDoorOpenID` in Go",
+			},
+			{
+				name:     "output not generated by hsmgen",
+				model:    doorModel("closed", "", "open()"),
+				existing: "package door\n",
+				err:      "exists and was not generated by hsmgen",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				args := c.args
+
+				if c.model != "" {
+					in, out := prepareFiles(t, c.model, c.existing)
+					args = append(args, "-in", in, "-out", out)
+				}
+
+				err := run(args, io.Discard)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+			})
+		}
+	})
+
+	t.Run("WHEN the output is the input THEN it is not overwritten", func(t *testing.T) {
+		in, _ := prepareFiles(t, doorModel("closed", "", "open()"), "")
+
+		err := run([]string{"-in", in, "-out", in, "-format", "json"}, io.Discard)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is the input file")
+
+		model, err := os.ReadFile(in)
+		require.NoError(t, err)
+		assert.Equal(t, doorModel("closed", "", "open()"), string(model))
+	})
+
+	t.Run("WHEN the output was generated by hsmgen THEN it is overwritten", func(t *testing.T) {
+		in, out := prepareFiles(t, doorModel("closed", "", "open()"), generatedHeader+" from door.json. DO NOT EDIT.\n")
+
+		require.NoError(t, run([]string{"-in", in, "-out", out, "-package", "door"}, io.Discard))
+
+		source, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Contains(t, string(source), "func NewDoorBuilder[C any](logic DoorLogic[C]) *hsm.Builder[C]")
+	})
+
+	t.Run("WHEN labels are not Go identifiers THEN they are sanitised", func(t *testing.T) {
+		in, out := prepareFiles(t, `{
+			"name": "door",
+			"start": "2 closed",
+			"error": {"id": "error"},
+			"states": [
+				{"id": "2 closed", "transitions": [{"signal": "open door", "guard": "is unlocked?", "target": "X final 2"}]},
+				{"id": "X final 2", "kind": "final"},
+				{"id": "Être", "onEntry": ["ring bell()"]}
+			]
+		}`, "")
+
+		require.NoError(t, run([]string{"-in", in, "-out", out, "-package", "door"}, io.Discard))
+
+		source, err := os.ReadFile(out)
+		require.NoError(t, err)
+
+		for _, expected := range []string{
+			"DoorX2ClosedID = \"2 closed\"",
+			"DoorXFinal2ID  = \"X final 2\"",
+			"DoorÊtreID     = \"Être\"",
+			"xFinal2State := hsm.NewFinalState[C]()",
+			"x2ClosedState := hsm.NewState[C]()",
+			"êtreState := hsm.NewState[C]()",
+			"OpenDoorSignal struct{}",
+			"IsUnlocked(ctx C) bool",
+			"RingBell(ctx C, signal hsm.Signal) error",
+		} {
+			assert.Contains(t, string(source), expected)
+		}
+	})
+}
+
+// doorModel returns a JSON door model starting at the given state, whose opening
+// transition is guarded and applies the given effect.
+func doorModel(start, guard, effect string) string {
+	return `{
+	"name": "door",
+	"start": "` + start + `",
+	"error": {"id": "error"},
+	"states": [
+		{"id": "closed", "transitions": [{"signal": "open", "guard": "` + guard + `", "effect": ["` + effect + `"], "target": "opened"}]},
+		{"id": "opened"}
+	]
+}`
+}
+
+// prepareFiles writes the given model as a JSON input file, and the given existing
+// content to the output file, if any.
+func prepareFiles(t *testing.T, model, existing string) (in, out string) {
+	dir := t.TempDir()
+	in = filepath.Join(dir, "door.json")
+	out = filepath.Join(dir, "door_hsm.go")
+
+	require.NoError(t, os.WriteFile(in, []byte(model), 0o644))
+
+	if existing != "" {
+		require.NoError(t, os.WriteFile(out, []byte(existing), 0o644))
+	}
+
+	return in, out
+}
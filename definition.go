@@ -0,0 +1,368 @@
+package hsm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Definition is a compiled and validated HSM definition, from which any number of
+// independent HSM instances can be cheaply created.
+//
+// Definitions are immutable and safe for concurrent use. They hold their own copy of the
+// states graph, so the vertices given to the builder are never modified and can be
+// safely shared among builders. Observers, middlewares, loggers and tracers are shared
+// by every instance created from the same definition.
+//
+// Usage:
+//
+//	def, err := hsm.NewBuilder[*MyContext]().
+//		WithName("my machine").
+//		...
+//		Definition()
+//
+//	machine := def.New(&MyContext{})
+type Definition[C any] struct {
+	// human-readable name of this machine
+	name string
+
+	// list of states within this machine
+	states map[string]*Vertex[C]
+
+	// every vertex within this machine, including those not registered as states (e.g.
+	// entry pseudo-states)
+	vertices []*Vertex[C]
+
+	// pointer to the starting state
+	start *Vertex[C]
+
+	// pointer to a state that will be entered whenever an error occurs in the state
+	// machine.
+	errorState *Vertex[C]
+
+	// whether failed transitions are rolled back to their source state instead of
+	// entering the error state
+	transactional bool
+
+	// how failed guards are handled
+	guardErrorPolicy GuardErrorPolicy
+
+	// how many transitions a single signal may take before a livelock is reported
+	maxMicrosteps int
+
+	// dispatch chain every signal goes through, including completion steps
+	dispatcher Dispatcher[C]
+
+	// logger used for reporting relevant events
+	logger Logger
+
+	// tracer used for creating spans, nil if tracing is disabled
+	tracer Tracer
+
+	// observers notified about the lifecycle of every instance
+	observers observers
+
+	// defines how much history is retained
+	historyPolicy HistoryPolicy
+
+	// names of every signal type known by this machine
+	kinds map[reflect.Type]string
+
+	// names of every signal matched by value, by signal type
+	values map[reflect.Type]map[Signal]string
+}
+
+// Name returns the name of the machines described by this definition.
+func (d *Definition[C]) Name() string {
+	return d.name
+}
+
+// States returns every vertex of the machines described by this definition, including
+// pseudo-states, sorted by ID. Vertices are read-only views of the compiled graph: they
+// can be given to builders, which copy rather than modify them.
+func (d *Definition[C]) States() []*Vertex[C] {
+	return append([]*Vertex[C](nil), d.vertices...)
+}
+
+// New creates a new HSM instance at its starting state, using the given context.
+func (d *Definition[C]) New(ctx C) *HSM[C] {
+	machine := &HSM[C]{
+		def:            d,
+		context:        ctx,
+		observers:      d.observers,
+		signalsHistory: make([]string, 0),
+		statesHistory:  make([]string, 0),
+	}
+
+	machine.write(d.start, true)
+
+	return machine
+}
+
+// Restore creates a new HSM instance using the given context and restores it from the
+// given snapshot. No guards are checked nor entry/exit logic will be executed. Histories
+// of the snapshot are copied and trimmed according to the history policy.
+func (d *Definition[C]) Restore(ctx C, snapshot Snapshot) (*HSM[C], error) {
+	state, ok := d.states[snapshot.StateID]
+	if !ok {
+		d.logger.Log(LogLevelError, "restore failed", "hsm", d.name, "state", snapshot.StateID)
+
+		return nil, fmt.Errorf("starting state `%s` does not exists", snapshot.StateID)
+	}
+
+	machine := d.New(ctx)
+	machine.microsteps = machine.microsteps[:0]
+	machine.write(state, false)
+
+	// histories are trimmed in place, so the snapshot ones are copied. Snapshots do not
+	// tell when states were entered, so they are considered entered when restored
+	if d.historyPolicy.enabled() {
+		now := time.Now()
+		entered := make([]time.Time, len(snapshot.StatesHistory))
+
+		for i := range entered {
+			entered[i] = now
+		}
+
+		machine.signalsHistory, machine.statesHistory, machine.statesEnteredAt, machine.history = d.historyPolicy.retain(
+			append([]string(nil), snapshot.SignalsHistory...),
+			append([]string(nil), snapshot.StatesHistory...),
+			entered,
+			append([]HistoryEntry(nil), snapshot.History...),
+			now,
+		)
+	}
+
+	// force hsm to progress if nil signal can be triggered
+	machine.watch()
+
+	machine.startRestoreTrace()
+
+	err := machine.tryProgress()
+	machine.endTrace(err)
+
+	if err != nil {
+		d.logger.Log(LogLevelError, "restore failed", "hsm", d.name, "state", snapshot.StateID, "error", err)
+
+		return nil, err
+	}
+
+	d.logger.Log(LogLevelInfo, "restored from snapshot", "hsm", d.name, "state", machine.currentState.id)
+
+	return machine, nil
+}
+
+// compile creates a new definition holding a private copy of the states graph described
+// by the given draft, transitions are not wired yet.
+func compile[C any](draft *Definition[C], middlewares []Middleware[C]) *Definition[C] {
+	def := &Definition[C]{
+		name:             draft.name,
+		states:           make(map[string]*Vertex[C], len(draft.states)),
+		transactional:    draft.transactional,
+		guardErrorPolicy: draft.guardErrorPolicy,
+		maxMicrosteps:    draft.maxMicrosteps,
+		dispatcher:       chain(middlewares),
+		logger:           draft.logger,
+		tracer:           draft.tracer,
+		observers:        append(observers(nil), draft.observers...),
+		historyPolicy:    draft.historyPolicy,
+	}
+
+	copies := make(map[*Vertex[C]]*Vertex[C])
+
+	var clone func(v *Vertex[C]) *Vertex[C]
+	clone = func(v *Vertex[C]) *Vertex[C] {
+		if v == nil {
+			return nil
+		}
+
+		if c, ok := copies[v]; ok {
+			return c
+		}
+
+		c := &Vertex[C]{
+			id:       v.id,
+			kind:     v.kind,
+			onEntry:  v.onEntry,
+			onExit:   v.onExit,
+			edges:    newEdgesCollection[C](),
+			compiled: true,
+		}
+
+		copies[v] = c
+		c.parent = clone(v.parent)
+		c.entryState = clone(v.entryState)
+
+		if v.edges != nil {
+			c.edges = v.edges.clone(func(t *Transition[C]) *Transition[C] {
+				wired := *t
+				if wired.kind == TransitionKindInternal {
+					wired.nextStateID = v.id
+				}
+
+				wired.nextStatePtr = nil
+
+				return &wired
+			})
+		}
+
+		return c
+	}
+
+	for id, s := range draft.states {
+		def.states[id] = clone(s)
+	}
+
+	def.start = clone(draft.start)
+	def.errorState = clone(draft.errorState)
+
+	for _, v := range copies {
+		def.vertices = append(def.vertices, v)
+	}
+
+	sort.Slice(def.vertices, func(i, j int) bool {
+		return def.vertices[i].id < def.vertices[j].id
+	})
+
+	return def
+}
+
+// wire links every transition of this definition to its target state, and precompiles
+// the dispatch table of every vertex.
+func (d *Definition[C]) wire() error {
+	d.kinds = make(map[reflect.Type]string)
+	d.values = make(map[reflect.Type]map[Signal]string)
+
+	for _, v := range d.vertices {
+		for _, t := range v.edges.list() {
+			target, ok := d.states[t.nextStateID]
+			if !ok {
+				return fmt.Errorf("state `%s` not found for transition", t.nextStateID)
+			}
+
+			t.nextStatePtr = target
+			t.source = v
+
+			// signals matched by value are named after their value rather than their type
+			switch m := t.matcher.(type) {
+			case *typeMatcher:
+				d.kinds[m.typ] = kindOf(m.proto)
+			case *valueMatcher:
+				if d.values[m.typ] == nil {
+					d.values[m.typ] = make(map[Signal]string)
+				}
+
+				d.values[m.typ][m.value] = valueName(m.value)
+			}
+		}
+	}
+
+	for _, v := range d.vertices {
+		v.children = nil
+	}
+
+	for _, v := range d.vertices {
+		if v.parent != nil {
+			v.parent.children = append(v.parent.children, v)
+		}
+
+		v.table = make(map[reflect.Type][]*Transition[C])
+		v.fallback, v.candidates = nil, nil
+
+		for ancestor := v; ancestor != nil; ancestor = ancestor.parent {
+			for signal := range ancestor.edges.edges {
+				v.table[signal] = nil
+			}
+
+			v.fallback = append(v.fallback, ancestor.edges.dynamic...)
+		}
+
+		// at every level, transitions indexed by type go before those using dynamic
+		// matchers, which never take completion transitions
+		for signal := range v.table {
+			for ancestor := v; ancestor != nil; ancestor = ancestor.parent {
+				v.table[signal] = append(v.table[signal], ancestor.edges.edges[signal]...)
+
+				if signal != nil {
+					v.table[signal] = append(v.table[signal], ancestor.edges.dynamic...)
+				}
+			}
+		}
+
+		for ancestor := v; ancestor != nil; ancestor = ancestor.parent {
+			for _, transitions := range ancestor.edges.edges {
+				v.candidates = append(v.candidates, transitions...)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate ensures the integrity of the given vertex and all its parts
+//
+//nolint:gocyclo
+func (d *Definition[C]) validateVertex(v *Vertex[C]) error {
+	if v.id == "" {
+		return fmt.Errorf("invalid state identity, cannot be empty")
+	}
+
+	if v.parent != nil {
+		if _, ok := d.states[v.parent.id]; !ok {
+			return fmt.Errorf("invalid state parent, parent state `%s` was not found in this machine", v.parent.id)
+		}
+	}
+
+	if v.onEntry != nil {
+		if v.onEntry.label == "" {
+			return fmt.Errorf("invalid state entry logic, no action label was provided")
+		}
+
+		if v.onEntry.method == nil {
+			return fmt.Errorf("invalid state entry logic, no method was defined")
+		}
+	}
+
+	if v.onExit != nil {
+		if v.onExit.label == "" {
+			return fmt.Errorf("invalid state exit logic, no label was provided")
+		}
+
+		if v.onExit.method == nil {
+			return fmt.Errorf("invalid state exit logic, no method was defined")
+		}
+	}
+
+	for _, t := range v.edges.list() {
+		if t.nextStateID == "" {
+			return fmt.Errorf("invalid transition, no next state was provided")
+		}
+
+		if _, ok := d.states[t.nextStateID]; !ok {
+			return fmt.Errorf("invalid transition, no next state `%s` does not exists", t.nextStateID)
+		}
+
+		if v.kind == VertexKindFinal {
+			return fmt.Errorf("invalid transition, final states cannot have outgoing transitions")
+		}
+
+		if v.kind == VertexKindError {
+			return fmt.Errorf("invalid transition, error states cannot have outgoing transitions")
+		}
+
+		if t.guard != nil && t.guard.label == "" {
+			return fmt.Errorf("invalid transition, nameless guard provided")
+		}
+
+		if t.guard != nil && t.guard.method == nil && t.guard.signalMethod == nil && t.guard.fallibleMethod == nil {
+			return fmt.Errorf("invalid transition, guard `%s` has no method", t.guard.label)
+		}
+
+		if t.effect != nil && t.effect.label == "" {
+			return fmt.Errorf("invalid transition, effects must provide a valid human-readable representation")
+		}
+	}
+
+	return nil
+}
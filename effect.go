@@ -2,8 +2,9 @@ package hsm
 
 // Effect definition of transition effect.
 type Effect[C any] struct {
-	label  string
-	method ActionFunc[C]
+	label        string
+	method       ActionFunc[C]
+	compensation ActionFunc[C]
 }
 
 // NewEffect returns a new effect builder.
@@ -4,13 +4,15 @@ package hsm
 type EffectBuilder[C any] interface {
 	WithLabel(label string) EffectBuilder[C]
 	WithMethod(method ActionFunc[C]) EffectBuilder[C]
+	WithCompensation(method ActionFunc[C]) EffectBuilder[C]
 	Build() *Effect[C]
 }
 
 // effectBuilder private effect builder.
 type effectBuilder[C any] struct {
-	label  string
-	method ActionFunc[C]
+	label        string
+	method       ActionFunc[C]
+	compensation ActionFunc[C]
 }
 
 // WithLabel defines effect's label.
@@ -27,10 +29,19 @@ func (b *effectBuilder[C]) WithMethod(method ActionFunc[C]) EffectBuilder[C] {
 	return b
 }
 
+// WithCompensation defines a compensating method for this effect, it is called to undo
+// the effect whenever a later step of the same transition fails.
+func (b *effectBuilder[C]) WithCompensation(method ActionFunc[C]) EffectBuilder[C] {
+	b.compensation = method
+
+	return b
+}
+
 // Build builds and returns the effect.
 func (b *effectBuilder[C]) Build() *Effect[C] {
 	return &Effect[C]{
-		label:  b.label,
-		method: b.method,
+		label:        b.label,
+		method:       b.method,
+		compensation: b.compensation,
 	}
 }
@@ -0,0 +1,146 @@
+package examples_test
+
+import (
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/botchris/go-hsm/examples/generated/parking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Run("WHEN analyzing a sound machine THEN nothing is reported", func(t *testing.T) {
+		findings, err := hsm.Analyze(prepareParkingBuilder())
+		require.NoError(t, err)
+
+		assert.Empty(t, findings)
+	})
+
+	t.Run("WHEN analyzing a flawed machine THEN every issue is reported at its vertex", func(t *testing.T) {
+		findings, err := hsm.Analyze(prepareFlawedBuilder())
+		require.NoError(t, err)
+
+		var reported []string
+		for _, finding := range findings {
+			reported = append(reported, finding.VertexID+": "+finding.Kind.String()+" ("+finding.Severity.String()+")")
+		}
+
+		assert.Equal(t, []string{
+			"box: entry parent mismatch (error)",
+			"busy: completion cycle (error)",
+			"idle: ambiguous transitions (error)",
+			"orphan: unreachable (warning)",
+			"orphan: dead end (warning)",
+			"pick: choice without else (warning)",
+		}, reported)
+	})
+
+	t.Run("WHEN a finding is printed THEN it reads with its severity and vertex", func(t *testing.T) {
+		findings, err := hsm.Analyze(prepareFlawedBuilder())
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			"error: state `busy`: states `busy`, `spin` are linked by signal-less transitions only, which loop forever",
+			findings[1].String(),
+		)
+		assert.Equal(t,
+			"error: state `idle`: unguarded transitions on signal `*flawSignal` go to `busy` and `done`, only the first one is ever taken",
+			findings[2].String(),
+		)
+	})
+
+	t.Run("WHEN a builder does not compile THEN its error is returned", func(t *testing.T) {
+		_, err := hsm.Analyze(hsm.NewBuilder[*flawContext]())
+		assert.Error(t, err)
+	})
+}
+
+func prepareParkingBuilder() *hsm.Builder[*parkingLogic] {
+	logic := &parkingLogic{}
+
+	return parking.NewParkingBuilder[*parkingLogic](logic).WithContext(logic)
+}
+
+func prepareFlawedBuilder() *hsm.Builder[*flawContext] {
+	return hsm.NewBuilder[*flawContext]().
+		WithName("flawed").
+		StartingAt(flawStart).
+		WithErrorState(hsm.NewErrorState[*flawContext]().WithID("error").Build()).
+		AddStates(flawStart, flawIdle, flawBusy, flawSpin, flawPick, flawBox, flawBoxEntry, flawInside, flawCrate, flawOrphan, flawDone)
+}
+
+// SIGNALS & CONTEXT.
+type (
+	flawContext struct{}
+	flawSignal  struct{}
+	boxSignal   struct{}
+	pickSignal  struct{}
+)
+
+// MACHINE PARTS.
+var flawStart = hsm.NewStart[*flawContext]().
+	WithID("start").
+	AddTransitions(hsm.NewTransition[*flawContext]().GoTo("idle").Build()).
+	Build()
+
+var flawIdle = hsm.NewState[*flawContext]().
+	WithID("idle").
+	AddTransitions(
+		hsm.NewTransition[*flawContext]().When(&flawSignal{}).GoTo("busy").Build(),
+		hsm.NewTransition[*flawContext]().When(&flawSignal{}).GoTo("done").Build(),
+		hsm.NewTransition[*flawContext]().When(&boxSignal{}).GoTo("box").Build(),
+		hsm.NewTransition[*flawContext]().When(&pickSignal{}).GoTo("pick").Build(),
+	).
+	Build()
+
+// busy and spin lead to each other forever
+var flawBusy = hsm.NewState[*flawContext]().
+	WithID("busy").
+	AddTransitions(hsm.NewTransition[*flawContext]().GoTo("spin").Build()).
+	Build()
+
+var flawSpin = hsm.NewState[*flawContext]().
+	WithID("spin").
+	AddTransitions(hsm.NewTransition[*flawContext]().GoTo("busy").Build()).
+	Build()
+
+var flawPick = hsm.NewChoice[*flawContext]().
+	WithID("pick").
+	AddTransitions(
+		hsm.NewTransition[*flawContext]().
+			GuardedBy(hsm.NewGuard[*flawContext]().WithLabel("lucky").WithMethod(func(*flawContext) bool { return true }).Build()).
+			GoTo("done").
+			Build(),
+	).
+	Build()
+
+var flawBoxEntry = hsm.NewEntryState[*flawContext]().
+	WithID("box entry").
+	AddTransitions(hsm.NewTransition[*flawContext]().GoTo("inside").Build()).
+	Build()
+
+var flawBox = hsm.NewState[*flawContext]().
+	WithID("box").
+	WithEntryState(flawBoxEntry).
+	AddTransitions(hsm.NewTransition[*flawContext]().When(&boxSignal{}).GoTo("done").Build()).
+	Build()
+
+var flawInside = hsm.NewState[*flawContext]().
+	WithID("inside").
+	ParentOf(flawBox).
+	Build()
+
+// crate reuses the entry state of box, which is now entered as a child of crate
+var flawCrate = hsm.NewState[*flawContext]().
+	WithID("crate").
+	WithEntryState(flawBoxEntry).
+	Build()
+
+var flawOrphan = hsm.NewState[*flawContext]().
+	WithID("orphan").
+	Build()
+
+var flawDone = hsm.NewFinalState[*flawContext]().
+	WithID("done").
+	Build()
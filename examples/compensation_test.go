@@ -0,0 +1,223 @@
+package examples_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompensation(t *testing.T) {
+	t.Run("WHEN onEntry fails THEN completed steps are compensated in reverse order", func(t *testing.T) {
+		context := &sagaContext{failEntry: true}
+		machine, err := prepareSagaMachine(context, false)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		assert.Error(t, machine.Signal(&sagaSignal{}))
+		assert.True(t, machine.Failed())
+		assert.Equal(t, "exit(); reserve(); undo reserve(); undo exit();", strings.Join(context.calls, "; ")+";")
+	})
+
+	t.Run("WHEN transition succeeds THEN no compensation is run", func(t *testing.T) {
+		context := &sagaContext{}
+		machine, err := prepareSagaMachine(context, false)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		assert.NoError(t, machine.Signal(&sagaSignal{}))
+		assert.True(t, machine.At(sagaB))
+		assert.Equal(t, []string{"exit()", "reserve()", "enter()"}, context.calls)
+	})
+
+	t.Run("WHEN transitions are not transactional THEN the context is never cloned", func(t *testing.T) {
+		context := &sagaContext{failEntry: true}
+		machine, err := prepareSagaMachine(context, false)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		assert.Error(t, machine.Signal(&sagaSignal{}))
+		assert.Zero(t, context.clones)
+		assert.Same(t, context, machine.Context())
+	})
+
+	t.Run("WHEN transitions are transactional THEN the context is cloned before every transition", func(t *testing.T) {
+		context := &sagaContext{}
+		machine, err := prepareSagaMachine(context, true)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		assert.NoError(t, machine.Signal(&sagaSignal{}))
+		assert.Equal(t, 1, context.clones)
+	})
+
+	t.Run("WHEN transactional transition fails THEN machine and context are rolled back", func(t *testing.T) {
+		context := &sagaContext{failEntry: true, balance: 10}
+		machine, err := prepareSagaMachine(context, true)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		assert.Error(t, machine.Signal(&sagaSignal{}))
+		assert.False(t, machine.Failed())
+		assert.True(t, machine.At(sagaA))
+		assert.Equal(t, 10, machine.Context().balance)
+		assert.Empty(t, machine.Context().calls)
+	})
+
+	t.Run("WHEN compensation fails THEN machine goes to error state", func(t *testing.T) {
+		context := &sagaContext{failEntry: true, failCompensation: true}
+		machine, err := prepareSagaMachine(context, true)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		err = machine.Signal(&sagaSignal{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "compensation failed")
+		assert.True(t, machine.Failed())
+	})
+
+	t.Run("WHEN context is read while transitions are rolled back THEN the restored context is read safely", func(t *testing.T) {
+		context := &sagaContext{failEntry: true, balance: 10}
+		machine, err := prepareSagaMachine(context, true)
+
+		require.NoError(t, err)
+		require.NotNil(t, machine)
+
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				assert.Error(t, machine.Signal(&sagaSignal{}))
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			assert.NotNil(t, machine.Context())
+		}
+
+		wg.Wait()
+
+		assert.True(t, machine.At(sagaA))
+		assert.Equal(t, 10, machine.Context().balance)
+	})
+}
+
+func prepareSagaMachine(context *sagaContext, transactional bool) (*hsm.HSM[*sagaContext], error) {
+	builder := hsm.NewBuilder[*sagaContext]().
+		// meta
+		WithName("saga").
+		WithContext(context).
+		StartingAt(sagaA).
+		WithErrorState(hsm.NewErrorState[*sagaContext]().WithID("error").Build()).
+
+		// states
+		AddState(sagaA).
+		AddState(sagaB)
+
+	if transactional {
+		builder = builder.WithTransactionalTransitions()
+	}
+
+	return builder.Build()
+}
+
+// SIGNALS & CONTEXT
+type (
+	sagaSignal  struct{}
+	sagaContext struct {
+		balance          int
+		calls            []string
+		failEntry        bool
+		failCompensation bool
+		clones           int
+	}
+)
+
+// Clone implements hsm.Cloner.
+func (c *sagaContext) Clone() *sagaContext {
+	c.clones++
+
+	clone := *c
+	clone.calls = append([]string(nil), c.calls...)
+
+	return &clone
+}
+
+// MACHINE PARTS
+var sagaA = hsm.NewState[*sagaContext]().
+	WithID("A").
+	OnExit(
+		hsm.NewAction[*sagaContext]().
+			WithLabel("exit()").
+			WithMethod(func(ctx *sagaContext, signal hsm.Signal) error {
+				ctx.calls = append(ctx.calls, "exit()")
+
+				return nil
+			}).
+			WithCompensation(func(ctx *sagaContext, signal hsm.Signal) error {
+				ctx.calls = append(ctx.calls, "undo exit()")
+
+				return nil
+			}).
+			Build(),
+	).
+	AddTransitions(
+		// A -saga/reserve()-> B
+		hsm.NewTransition[*sagaContext]().
+			When(&sagaSignal{}).
+			ApplyEffect(
+				hsm.NewEffect[*sagaContext]().
+					WithLabel("reserve()").
+					WithMethod(func(ctx *sagaContext, signal hsm.Signal) error {
+						ctx.balance -= 5
+						ctx.calls = append(ctx.calls, "reserve()")
+
+						return nil
+					}).
+					WithCompensation(func(ctx *sagaContext, signal hsm.Signal) error {
+						if ctx.failCompensation {
+							return fmt.Errorf("dummy error, undo reserve")
+						}
+
+						ctx.calls = append(ctx.calls, "undo reserve()")
+
+						return nil
+					}).
+					Build(),
+			).
+			GoTo("B").
+			Build(),
+	).
+	Build()
+
+var sagaB = hsm.NewState[*sagaContext]().
+	WithID("B").
+	OnEntry(
+		hsm.NewAction[*sagaContext]().
+			WithLabel("enter()").
+			WithMethod(func(ctx *sagaContext, signal hsm.Signal) error {
+				if ctx.failEntry {
+					return fmt.Errorf("dummy error, enter B")
+				}
+
+				ctx.calls = append(ctx.calls, "enter()")
+
+				return nil
+			}).
+			Build(),
+	).
+	Build()
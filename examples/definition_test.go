@@ -0,0 +1,129 @@
+package examples_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinition(t *testing.T) {
+	t.Run("WHEN many instances are created THEN they run independently", func(t *testing.T) {
+		def, err := doorDefinitionBuilder().Definition()
+		require.NoError(t, err)
+		assert.Equal(t, "door", def.Name())
+
+		var (
+			wg       sync.WaitGroup
+			machines = make([]*hsm.HSM[*doorContext], 100)
+		)
+
+		for i := range machines {
+			machines[i] = def.New(&doorContext{})
+		}
+
+		for i, machine := range machines {
+			wg.Add(1)
+
+			go func(i int, machine *hsm.HSM[*doorContext]) {
+				defer wg.Done()
+
+				for j := 0; j <= i%3; j++ {
+					assert.NoError(t, machine.Signal(&handleSignal{}))
+				}
+			}(i, machine)
+		}
+
+		wg.Wait()
+
+		for i, machine := range machines {
+			assert.Equal(t, i%3 != 1, machine.At(closedState), "machine %d", i)
+			assert.Equal(t, i%3+1, len(machine.Snapshot().History))
+			assert.Equal(t, def, machine.Definition())
+		}
+	})
+
+	t.Run("WHEN restoring from a definition THEN instance starts at snapshot state", func(t *testing.T) {
+		def, err := doorDefinitionBuilder().Definition()
+		require.NoError(t, err)
+
+		machine, err := def.Restore(&doorContext{}, hsm.Snapshot{StateID: closedID})
+		require.NoError(t, err)
+		assert.True(t, machine.At(closedState))
+
+		_, err = def.Restore(&doorContext{}, hsm.Snapshot{StateID: "unknown"})
+		assert.Error(t, err)
+	})
+
+	t.Run("WHEN vertices are shared by definitions THEN each definition keeps its own wiring", func(t *testing.T) {
+		source := hsm.NewState[*sharedContext]().
+			WithID("source").
+			AddTransitions(
+				hsm.NewTransition[*sharedContext]().
+					When(&sharedSignal{}).
+					GoTo("target").
+					Build(),
+			).
+			Build()
+
+		first, err := prepareSharedDefinition(source, "first")
+		require.NoError(t, err)
+
+		second, err := prepareSharedDefinition(source, "second")
+		require.NoError(t, err)
+
+		context := &sharedContext{}
+		require.NoError(t, first.New(context).Signal(&sharedSignal{}))
+		require.NoError(t, second.New(context).Signal(&sharedSignal{}))
+		require.NoError(t, first.New(context).Signal(&sharedSignal{}))
+
+		assert.Equal(t, []string{"first", "second", "first"}, context.entered)
+	})
+}
+
+func doorDefinitionBuilder() *hsm.Builder[*doorContext] {
+	return hsm.NewBuilder[*doorContext]().
+		// meta
+		WithName("door").
+		StartingAt(openState).
+		WithErrorState(hsm.NewErrorState[*doorContext]().WithID("error").Build()).
+
+		// states
+		AddState(openState).
+		AddState(closedState).
+		AddState(lockedState)
+}
+
+func prepareSharedDefinition(source *hsm.Vertex[*sharedContext], name string) (*hsm.Definition[*sharedContext], error) {
+	target := hsm.NewState[*sharedContext]().
+		WithID("target").
+		OnEntry(
+			hsm.NewAction[*sharedContext]().
+				WithLabel("enter()").
+				WithMethod(func(ctx *sharedContext, signal hsm.Signal) error {
+					ctx.entered = append(ctx.entered, name)
+
+					return nil
+				}).
+				Build(),
+		).
+		Build()
+
+	return hsm.NewBuilder[*sharedContext]().
+		WithName(name).
+		StartingAt(source).
+		WithErrorState(hsm.NewErrorState[*sharedContext]().WithID("error").Build()).
+		AddState(source).
+		AddState(target).
+		Definition()
+}
+
+// SIGNALS & CONTEXT
+type (
+	sharedSignal  struct{}
+	sharedContext struct {
+		entered []string
+	}
+)
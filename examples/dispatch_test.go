@@ -0,0 +1,97 @@
+package examples_test
+
+import (
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkSignal(b *testing.B) {
+	// compare both ns/op: observers must only cost when there are some
+	b.Run("unobserved", benchmarkSignal(hsm.DisabledHistory(), nil))
+	b.Run("observed", benchmarkSignal(hsm.DisabledHistory(), hsm.NopObserver{}))
+
+	// history policies: unlimited history allocates as it grows, ring history does not
+	b.Run("unlimited history", benchmarkSignal(hsm.UnlimitedHistory(), nil))
+	b.Run("ring history", benchmarkSignal(hsm.RingHistory(100), nil))
+}
+
+// benchmarkSignal benchmarks signals sent to a machine with the given history policy and
+// observer, if any.
+func benchmarkSignal(policy hsm.HistoryPolicy, observer hsm.Observer) func(b *testing.B) {
+	return func(b *testing.B) {
+		builder := hsm.NewBuilder[*pingContext]().
+			WithName("ping").
+			WithHistoryPolicy(policy).
+			StartingAt(pingState).
+			WithErrorState(hsm.NewErrorState[*pingContext]().WithID("error").Build()).
+			AddState(pingState).
+			AddState(pongState)
+
+		if observer != nil {
+			builder = builder.WithObserver(observer)
+		}
+
+		def, err := builder.Definition()
+		require.NoError(b, err)
+
+		machine := def.New(&pingContext{})
+		signal := &pingSignal{}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if err := machine.Signal(signal); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestSignalAllocations(t *testing.T) {
+	t.Run("WHEN signaling an unguarded transition with history disabled THEN no allocations are made", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.DisabledHistory())
+		require.NoError(t, err)
+
+		signal := &pingSignal{}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			require.NoError(t, machine.Signal(signal))
+		})
+
+		require.Zero(t, allocs)
+	})
+
+	t.Run("WHEN signaling an unguarded transition with a full ring history THEN no allocations are made", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.RingHistory(10))
+		require.NoError(t, err)
+
+		signal := &pingSignal{}
+
+		// fill the ring up to its compaction threshold
+		for i := 0; i < 30; i++ {
+			require.NoError(t, machine.Signal(signal))
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			require.NoError(t, machine.Signal(signal))
+		})
+
+		require.Zero(t, allocs)
+	})
+
+	t.Run("WHEN checking whether a signal can be sent THEN no allocations are made", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.DisabledHistory())
+		require.NoError(t, err)
+
+		signal := &pingSignal{}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			require.True(t, machine.Can(signal))
+		})
+
+		require.Zero(t, allocs)
+	})
+}
@@ -0,0 +1,5 @@
+// Package parking holds the wiring of a parking barrier machine, generated by hsmgen
+// from parking.puml.
+package parking
+
+//go:generate go run ../../../cmd/hsmgen -in parking.puml
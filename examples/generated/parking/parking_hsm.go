@@ -0,0 +1,136 @@
+// Code generated by hsmgen from parking.puml. DO NOT EDIT.
+
+package parking
+
+import "github.com/botchris/go-hsm"
+
+// State IDs of the parking machine.
+const (
+	ParkingErrorID     = "error"
+	ParkingStartID     = "start"
+	ParkingClosedID    = "closed"
+	ParkingCheckingID  = "checking"
+	ParkingOpenID      = "open"
+	ParkingOpenEntryID = "open entry"
+	ParkingRaisingID   = "raising"
+	ParkingOpenFinalID = "open final"
+)
+
+// Signals of the parking machine.
+type (
+	PassedSignal struct{}
+	TickSignal   struct{}
+	TicketSignal struct{}
+)
+
+// ParkingLogic is implemented by the guards, actions and effects of the parking machine.
+type ParkingLogic[C any] interface {
+	// Valid implements the `valid` guard.
+	Valid(ctx C) bool
+	// Alarm implements the `alarm` action.
+	Alarm(ctx C, signal hsm.Signal) error
+	// Count implements the `count` action.
+	Count(ctx C, signal hsm.Signal) error
+	// Light implements the `light` action.
+	Light(ctx C, signal hsm.Signal) error
+	// Lower implements the `lower` action.
+	Lower(ctx C, signal hsm.Signal) error
+	// Raise implements the `raise` action.
+	Raise(ctx C, signal hsm.Signal) error
+}
+
+// NewParkingBuilder returns a builder of the parking machine, running the given logic.
+func NewParkingBuilder[C any](logic ParkingLogic[C]) *hsm.Builder[C] {
+	errorState := hsm.NewErrorState[C]().
+		WithID(ParkingErrorID).
+		OnEntry(hsm.NewAction[C]().WithLabel("alarm").WithMethod(logic.Alarm).Build()).
+		Build()
+
+	startState := hsm.NewStart[C]().
+		WithID(ParkingStartID).
+		AddTransitions(
+			hsm.NewTransition[C]().
+				GoTo(ParkingClosedID).
+				Build(),
+		).
+		Build()
+
+	closedState := hsm.NewState[C]().
+		WithID(ParkingClosedID).
+		OnEntry(hsm.NewAction[C]().WithLabel("lower; light").WithMethod(func(ctx C, signal hsm.Signal) error {
+			if err := logic.Lower(ctx, signal); err != nil {
+				return err
+			}
+
+			return logic.Light(ctx, signal)
+		}).Build()).
+		AddTransitions(
+			hsm.NewTransition[C]().
+				When(&TicketSignal{}).
+				GoTo(ParkingCheckingID).
+				Build(),
+			hsm.NewInternalTransition[C]().
+				When(&TickSignal{}).
+				ApplyEffect(hsm.NewEffect[C]().WithLabel("count").WithMethod(logic.Count).Build()).
+				Build(),
+		).
+		Build()
+
+	checkingState := hsm.NewChoice[C]().
+		WithID(ParkingCheckingID).
+		AddTransitions(
+			hsm.NewTransition[C]().
+				GuardedBy(hsm.NewGuard[C]().WithLabel("valid").WithMethod(logic.Valid).Build()).
+				GoTo(ParkingOpenID).
+				Build(),
+			hsm.NewTransition[C]().
+				GoTo(ParkingClosedID).
+				Build(),
+		).
+		Build()
+
+	openEntryState := hsm.NewEntryState[C]().
+		WithID(ParkingOpenEntryID).
+		AddTransitions(
+			hsm.NewTransition[C]().
+				ApplyEffect(hsm.NewEffect[C]().WithLabel("raise").WithMethod(logic.Raise).Build()).
+				GoTo(ParkingRaisingID).
+				Build(),
+		).
+		Build()
+
+	openState := hsm.NewState[C]().
+		WithID(ParkingOpenID).
+		WithEntryState(openEntryState).
+		AddTransitions(
+			hsm.NewTransition[C]().
+				When(&PassedSignal{}).
+				GoTo(ParkingOpenFinalID).
+				Build(),
+		).
+		Build()
+
+	raisingState := hsm.NewState[C]().
+		WithID(ParkingRaisingID).
+		ParentOf(openState).
+		Build()
+
+	openFinalState := hsm.NewFinalState[C]().
+		WithID(ParkingOpenFinalID).
+		ParentOf(openState).
+		Build()
+
+	return hsm.NewBuilder[C]().
+		WithName("parking").
+		StartingAt(startState).
+		WithErrorState(errorState).
+		AddStates(
+			startState,
+			closedState,
+			checkingState,
+			openState,
+			openEntryState,
+			raisingState,
+			openFinalState,
+		)
+}
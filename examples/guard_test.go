@@ -0,0 +1,286 @@
+package examples_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalAwareGuards(t *testing.T) {
+	t.Run("WHEN payload satisfies the guard THEN transition is taken", func(t *testing.T) {
+		machine, err := prepareLimitMachine(&limitContext{limit: 100})
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&limitDeposit{Amount: 500}))
+		assert.True(t, machine.At(limitReviewState))
+	})
+
+	t.Run("WHEN payload does not satisfy the guard THEN next transition is taken", func(t *testing.T) {
+		machine, err := prepareLimitMachine(&limitContext{limit: 100})
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&limitDeposit{Amount: 50}))
+		assert.True(t, machine.At(limitIdleState))
+		assert.Equal(t, []string{"*limitDeposit"}, machine.Snapshot().SignalsHistory)
+	})
+
+	t.Run("WHEN checking with a prototype THEN signal-aware guards are assumed to hold", func(t *testing.T) {
+		machine, err := prepareLimitMachine(&limitContext{limit: 100})
+		require.NoError(t, err)
+
+		assert.True(t, machine.Can(&limitWithdrawal{}))
+		assert.Len(t, machine.AvailableSignals(), 2)
+	})
+
+	t.Run("WHEN checking with a concrete signal THEN signal-aware guards are evaluated", func(t *testing.T) {
+		machine, err := prepareLimitMachine(&limitContext{limit: 100})
+		require.NoError(t, err)
+
+		assert.False(t, machine.CanWith(&limitWithdrawal{Amount: 500}))
+		assert.True(t, machine.CanWith(&limitWithdrawal{Amount: 50}))
+		assert.True(t, machine.CanWith(&limitDeposit{Amount: 50}))
+	})
+}
+
+func TestFallibleGuards(t *testing.T) {
+	t.Run("WHEN guard fails THEN a guard error is returned and no exit action is run", func(t *testing.T) {
+		context := &flagContext{err: errors.New("flags unavailable")}
+		machine, err := prepareFlagMachine(context, hsm.GuardErrorAbort)
+		require.NoError(t, err)
+
+		err = machine.Signal(&flagSignal{})
+
+		var guardErr *hsm.GuardError
+		require.ErrorAs(t, err, &guardErr)
+		assert.ErrorIs(t, err, context.err)
+		assert.Equal(t, "flag enabled", guardErr.Guard)
+		assert.Equal(t, flagOffID, guardErr.State)
+		assert.Equal(t, "*flagSignal", guardErr.Signal)
+
+		assert.True(t, machine.At(flagOffState))
+		assert.Empty(t, context.exited)
+	})
+
+	t.Run("WHEN guard fails and policy enters error state THEN machine is at error state", func(t *testing.T) {
+		context := &flagContext{err: errors.New("flags unavailable")}
+		machine, err := prepareFlagMachine(context, hsm.GuardErrorEnterErrorState)
+		require.NoError(t, err)
+
+		var guardErr *hsm.GuardError
+		require.ErrorAs(t, machine.Signal(&flagSignal{}), &guardErr)
+		assert.True(t, machine.Failed())
+		assert.Empty(t, context.exited)
+	})
+
+	t.Run("WHEN guard succeeds THEN transition is taken", func(t *testing.T) {
+		context := &flagContext{enabled: true}
+		machine, err := prepareFlagMachine(context, hsm.GuardErrorAbort)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&flagSignal{}))
+		assert.True(t, machine.At(flagOnState))
+		assert.Equal(t, []string{flagOffID}, context.exited)
+	})
+
+	t.Run("WHEN checking a failing guard THEN it does not hold", func(t *testing.T) {
+		context := &flagContext{enabled: true, err: errors.New("flags unavailable")}
+		machine, err := prepareFlagMachine(context, hsm.GuardErrorAbort)
+		require.NoError(t, err)
+
+		assert.True(t, machine.Can(&flagSignal{}))
+		assert.False(t, machine.CanWith(&flagSignal{}))
+	})
+
+	t.Run("WHEN a completion guard holds once THEN it is evaluated once and its transition is taken", func(t *testing.T) {
+		context := &rolloutContext{}
+		machine, err := prepareRolloutMachine(context)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(hsm.Named("deploy")))
+		assert.Equal(t, "canary", machine.Current().ID())
+		assert.Equal(t, 1, context.checks)
+	})
+}
+
+func prepareRolloutMachine(context *rolloutContext) (*hsm.HSM[*rolloutContext], error) {
+	// the guard only holds the first time it is checked, as a feature flag flipping
+	// between checks would
+	flipping := hsm.NewGuard[*rolloutContext]().
+		WithLabel("canary enabled").
+		WithFallibleMethod(func(ctx *rolloutContext, _ hsm.Signal) (bool, error) {
+			ctx.checks++
+
+			if ctx.checks > 1 {
+				return false, errors.New("flag service unavailable")
+			}
+
+			return true, nil
+		}).
+		Build()
+
+	idle := hsm.NewState[*rolloutContext]().
+		WithID("idle").
+		AddTransitions(hsm.NewTransition[*rolloutContext]().When(hsm.Named("deploy")).GoTo("deploying").Build()).
+		Build()
+
+	deploying := hsm.NewState[*rolloutContext]().
+		WithID("deploying").
+		AddTransitions(hsm.NewTransition[*rolloutContext]().GuardedBy(flipping).GoTo("canary").Build()).
+		Build()
+
+	return hsm.NewBuilder[*rolloutContext]().
+		WithName("rollout").
+		WithContext(context).
+		StartingAt(idle).
+		WithErrorState(hsm.NewErrorState[*rolloutContext]().WithID("error").Build()).
+		AddStates(idle, deploying, hsm.NewState[*rolloutContext]().WithID("canary").Build()).
+		Build()
+}
+
+func prepareLimitMachine(context *limitContext) (*hsm.HSM[*limitContext], error) {
+	return hsm.NewBuilder[*limitContext]().
+		// meta
+		WithName("limit").
+		WithContext(context).
+		StartingAt(limitIdleState).
+		WithErrorState(hsm.NewErrorState[*limitContext]().WithID("error").Build()).
+
+		// states
+		AddState(limitIdleState).
+		AddState(limitReviewState).
+
+		// build
+		Build()
+}
+
+func prepareFlagMachine(context *flagContext, policy hsm.GuardErrorPolicy) (*hsm.HSM[*flagContext], error) {
+	return hsm.NewBuilder[*flagContext]().
+		// meta
+		WithName("flag").
+		WithContext(context).
+		WithGuardErrorPolicy(policy).
+		StartingAt(flagOffState).
+		WithErrorState(hsm.NewErrorState[*flagContext]().WithID("error").Build()).
+
+		// states
+		AddState(flagOffState).
+		AddState(flagOnState).
+
+		// build
+		Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	flagSignal     struct{}
+	rolloutContext struct {
+		checks int
+	}
+	flagContext struct {
+		enabled bool
+		err     error
+		exited  []string
+	}
+
+	limitDeposit struct {
+		Amount int
+	}
+
+	limitWithdrawal struct {
+		Amount int
+	}
+
+	limitContext struct {
+		limit int
+	}
+)
+
+// STATE IDS.
+var (
+	flagOffID = "off"
+	flagOnID  = "on"
+
+	limitIdleID   = "idle"
+	limitReviewID = "review"
+)
+
+// MACHINE PARTS.
+var overLimit = hsm.NewGuard[*limitContext]().
+	WithLabel("amount > limit").
+	WithSignalMethod(func(ctx *limitContext, signal hsm.Signal) bool {
+		switch s := signal.(type) {
+		case *limitDeposit:
+			return s.Amount > ctx.limit
+		case *limitWithdrawal:
+			return s.Amount > ctx.limit
+		}
+
+		return false
+	}).
+	Build()
+
+var withinLimit = hsm.NewGuard[*limitContext]().
+	WithLabel("amount <= limit").
+	WithSignalMethod(func(ctx *limitContext, signal hsm.Signal) bool {
+		s, ok := signal.(*limitWithdrawal)
+
+		return ok && s.Amount <= ctx.limit
+	}).
+	Build()
+
+var limitIdleState = hsm.NewState[*limitContext]().
+	WithID(limitIdleID).
+	AddTransitions(
+		hsm.NewTransition[*limitContext]().
+			When(&limitDeposit{}).
+			GuardedBy(overLimit).
+			GoTo(limitReviewID).
+			Build(),
+		hsm.NewInternalTransition[*limitContext]().
+			When(&limitDeposit{}).
+			Build(),
+		hsm.NewInternalTransition[*limitContext]().
+			When(&limitWithdrawal{}).
+			GuardedBy(withinLimit).
+			Build(),
+	).
+	Build()
+
+var limitReviewState = hsm.NewState[*limitContext]().
+	WithID(limitReviewID).
+	Build()
+
+var flagOffState = hsm.NewState[*flagContext]().
+	WithID(flagOffID).
+	OnExit(
+		hsm.NewAction[*flagContext]().
+			WithLabel("record()").
+			WithMethod(func(ctx *flagContext, signal hsm.Signal) error {
+				ctx.exited = append(ctx.exited, flagOffID)
+
+				return nil
+			}).
+			Build(),
+	).
+	AddTransitions(
+		hsm.NewTransition[*flagContext]().
+			When(&flagSignal{}).
+			GuardedBy(
+				hsm.NewGuard[*flagContext]().
+					WithLabel("flag enabled").
+					WithFallibleMethod(func(ctx *flagContext, signal hsm.Signal) (bool, error) {
+						return ctx.enabled, ctx.err
+					}).
+					Build(),
+			).
+			GoTo(flagOnID).
+			Build(),
+	).
+	Build()
+
+var flagOnState = hsm.NewState[*flagContext]().
+	WithID(flagOnID).
+	Build()
@@ -0,0 +1,288 @@
+package examples_test
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryPolicy(t *testing.T) {
+	t.Run("WHEN ring policy is used THEN only latest entries are retained", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.RingHistory(3))
+		require.NoError(t, err)
+
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, machine.Signal(&pingSignal{}))
+		}
+
+		snapshot := machine.Snapshot()
+		require.Len(t, snapshot.History, 3)
+		assert.Len(t, snapshot.SignalsHistory, 3)
+		assert.Equal(t, []string{"ping", "pong", "ping"}, snapshot.StatesHistory)
+		assert.Equal(t, "pong", snapshot.History[1].Target)
+		assert.Equal(t, "ping", snapshot.History[2].Target)
+	})
+
+	t.Run("WHEN windowed policy is used THEN only recent entries are retained", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.WindowedHistory(50 * time.Millisecond))
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&pingSignal{}))
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, machine.Signal(&pingSignal{}))
+
+		snapshot := machine.Snapshot()
+		require.Len(t, snapshot.History, 1)
+		assert.Equal(t, "ping", snapshot.History[0].Target)
+		assert.Equal(t, []string{"*pingSignal"}, snapshot.SignalsHistory)
+		assert.Equal(t, []string{"pong", "ping"}, snapshot.StatesHistory)
+	})
+
+	t.Run("WHEN windowed policy is used with internal and error transitions THEN states are retained by time", func(t *testing.T) {
+		machine, err := prepareJobMachine(hsm.WindowedHistory(50 * time.Millisecond))
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(hsm.Named("start")))
+		time.Sleep(100 * time.Millisecond)
+
+		// internal transitions record entries but no state, errors record a state but no entry
+		require.NoError(t, machine.Signal(hsm.Named("tick")))
+		require.NoError(t, machine.Signal(hsm.Named("tick")))
+		require.Error(t, machine.Signal(hsm.Named("finish")))
+
+		snapshot := machine.Snapshot()
+		require.Len(t, snapshot.History, 2)
+		assert.Equal(t, hsm.HistoryKindInternal, snapshot.History[0].Kind)
+		assert.Equal(t, []string{"tick", "tick"}, snapshot.SignalsHistory)
+		assert.Equal(t, []string{"busy", "error"}, snapshot.StatesHistory)
+	})
+
+	t.Run("WHEN history is disabled THEN nothing is retained", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.DisabledHistory())
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&pingSignal{}))
+
+		snapshot := machine.Snapshot()
+		assert.Empty(t, snapshot.History)
+		assert.Empty(t, snapshot.SignalsHistory)
+		assert.Empty(t, snapshot.StatesHistory)
+		assert.Equal(t, "pong", snapshot.StateID)
+	})
+
+	t.Run("WHEN ring policy is used THEN memory stays flat over long runs", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.RingHistory(100))
+		require.NoError(t, err)
+
+		signal := func(n int) {
+			for i := 0; i < n; i++ {
+				require.NoError(t, machine.Signal(&pingSignal{}))
+			}
+		}
+
+		signal(10000)
+		before := heapInUse()
+		signal(100000)
+		after := heapInUse()
+
+		runtime.KeepAlive(machine)
+		assert.Less(t, int64(after)-int64(before), int64(1<<20))
+	})
+
+	t.Run("WHEN restoring a snapshot THEN its histories are copied and retained by policy", func(t *testing.T) {
+		source, err := preparePingMachine(hsm.UnlimitedHistory())
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, source.Signal(&pingSignal{}))
+		}
+
+		// snapshots decoded from storage usually have spare capacity
+		snapshot := source.Snapshot()
+		snapshot.SignalsHistory = append(make([]string, 0, 100), snapshot.SignalsHistory...)
+		snapshot.StatesHistory = append(make([]string, 0, 100), snapshot.StatesHistory...)
+		snapshot.History = append(make([]hsm.HistoryEntry, 0, 100), snapshot.History...)
+		original := source.Snapshot()
+
+		ring, err := preparePingMachine(hsm.RingHistory(3))
+		require.NoError(t, err)
+
+		machine, err := ring.Definition().Restore(&pingContext{}, snapshot)
+		require.NoError(t, err)
+
+		restored := machine.Snapshot()
+		assert.Len(t, restored.History, 3)
+		assert.Equal(t, []string{"ping", "pong", "ping"}, restored.StatesHistory)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, machine.Signal(&pingSignal{}))
+		}
+
+		assert.Equal(t, original, snapshot)
+	})
+
+	t.Run("WHEN snapshots are taken while signals are processed THEN histories are read safely", func(t *testing.T) {
+		machine, err := preparePingMachine(hsm.RingHistory(10))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				assert.NoError(t, machine.Signal(&pingSignal{}))
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			assert.LessOrEqual(t, len(machine.Snapshot().History), 10)
+		}
+
+		wg.Wait()
+
+		assert.Len(t, machine.Snapshot().History, 10)
+	})
+}
+
+func BenchmarkHistoryPolicy(b *testing.B) {
+	policies := map[string]hsm.HistoryPolicy{
+		"unlimited": hsm.UnlimitedHistory(),
+		"ring":      hsm.RingHistory(100),
+		"window":    hsm.WindowedHistory(time.Millisecond),
+		"disabled":  hsm.DisabledHistory(),
+	}
+
+	for name, policy := range policies {
+		policy := policy
+
+		b.Run(name, func(b *testing.B) {
+			machine, err := preparePingMachine(policy)
+			require.NoError(b, err)
+
+			signal := &pingSignal{}
+			before := heapInUse()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_ = machine.Signal(signal)
+			}
+
+			b.StopTimer()
+			b.ReportMetric(float64(int64(heapInUse())-int64(before)), "heap-B")
+			runtime.KeepAlive(machine)
+		})
+	}
+}
+
+func preparePingMachine(policy hsm.HistoryPolicy) (*hsm.HSM[*pingContext], error) {
+	return hsm.NewBuilder[*pingContext]().
+		// meta
+		WithName("ping").
+		WithContext(&pingContext{}).
+		WithHistoryPolicy(policy).
+		StartingAt(pingState).
+		WithErrorState(hsm.NewErrorState[*pingContext]().WithID("error").Build()).
+
+		// states
+		AddState(pingState).
+		AddState(pongState).
+
+		// build
+		Build()
+}
+
+func prepareJobMachine(policy hsm.HistoryPolicy) (*hsm.HSM[*pingContext], error) {
+	idle := hsm.NewState[*pingContext]().
+		WithID("idle").
+		AddTransitions(hsm.NewTransition[*pingContext]().When(hsm.Named("start")).GoTo("busy").Build()).
+		Build()
+
+	busy := hsm.NewState[*pingContext]().
+		WithID("busy").
+		AddTransitions(
+			hsm.NewInternalTransition[*pingContext]().When(hsm.Named("tick")).ApplyEffect(pingEffect).Build(),
+			hsm.NewTransition[*pingContext]().
+				When(hsm.Named("finish")).
+				ApplyEffect(
+					hsm.NewEffect[*pingContext]().
+						WithLabel("fail()").
+						WithMethod(func(ctx *pingContext, signal hsm.Signal) error {
+							return errors.New("job failed")
+						}).
+						Build(),
+				).
+				GoTo("idle").
+				Build(),
+		).
+		Build()
+
+	return hsm.NewBuilder[*pingContext]().
+		WithName("job").
+		WithContext(&pingContext{}).
+		WithHistoryPolicy(policy).
+		StartingAt(idle).
+		WithErrorState(hsm.NewErrorState[*pingContext]().WithID("error").Build()).
+		AddStates(idle, busy).
+		Build()
+}
+
+// SIGNALS & CONTEXT
+type (
+	pingSignal  struct{}
+	pingContext struct {
+		count int
+	}
+)
+
+// MACHINE PARTS
+var pingState = hsm.NewState[*pingContext]().
+	WithID("ping").
+	AddTransitions(
+		hsm.NewTransition[*pingContext]().
+			When(&pingSignal{}).
+			ApplyEffect(pingEffect).
+			GoTo("pong").
+			Build(),
+	).
+	Build()
+
+var pongState = hsm.NewState[*pingContext]().
+	WithID("pong").
+	AddTransitions(
+		hsm.NewTransition[*pingContext]().
+			When(&pingSignal{}).
+			ApplyEffect(pingEffect).
+			GoTo("ping").
+			Build(),
+	).
+	Build()
+
+var pingEffect = hsm.NewEffect[*pingContext]().
+	WithLabel("count++").
+	WithMethod(func(ctx *pingContext, signal hsm.Signal) error {
+		ctx.count++
+
+		return nil
+	}).
+	Build()
+
+// heapInUse returns heap memory in use after a full garbage collection.
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+
+	return stats.HeapInuse
+}
@@ -0,0 +1,108 @@
+package examples_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NOTE: this tests uses choice-machine example definition
+func TestHistoryEntries(t *testing.T) {
+	machine, err := prepareChoiceMachine(&choiceCtx{g4: true})
+
+	require.NoError(t, err)
+	require.NoError(t, machine.Signal(&choiceSignal{}))
+
+	history := machine.Snapshot().History
+	require.Len(t, history, 3)
+
+	assert.Equal(t, hsm.HistoryEntry{
+		Time:     history[0].Time,
+		Signal:   "nil",
+		Source:   "c0",
+		Target:   "c1",
+		Kind:     hsm.HistoryKindCompletion,
+		Duration: history[0].Duration,
+	}, history[0])
+
+	assert.Equal(t, hsm.HistoryEntry{
+		Time:     history[1].Time,
+		Signal:   "*choiceSignal",
+		Source:   "c1",
+		Target:   "c2",
+		Kind:     hsm.HistoryKindNormal,
+		Duration: history[1].Duration,
+	}, history[1])
+
+	assert.Equal(t, hsm.HistoryEntry{
+		Time:     history[2].Time,
+		Signal:   "nil",
+		Source:   "c2",
+		Target:   "c4",
+		Kind:     hsm.HistoryKindCompletion,
+		Guard:    "g4",
+		Duration: history[2].Duration,
+	}, history[2])
+
+	for i, entry := range history {
+		assert.False(t, entry.Time.IsZero())
+
+		if i > 0 {
+			assert.False(t, entry.Time.Before(history[i-1].Time))
+		}
+	}
+
+	assert.Contains(t, machine.TimeInState(), "c4")
+}
+
+// NOTE: this tests uses internal-transition-machine example definition
+func TestHistoryInternalEntries(t *testing.T) {
+	machine, err := prepareDummyMachine(&dummyCtx{})
+
+	require.NoError(t, err)
+	require.NoError(t, machine.Signal(&dummySignalOne{}))
+
+	history := machine.Snapshot().History
+	require.Len(t, history, 1)
+	assert.Equal(t, hsm.HistoryKindInternal, history[0].Kind)
+	assert.Equal(t, "dummy1", history[0].Source)
+	assert.Equal(t, "dummy1", history[0].Target)
+}
+
+func TestTimeInState(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := hsm.Snapshot{
+		StateID: "c",
+		History: []hsm.HistoryEntry{
+			{Time: start, Source: "a", Target: "b", Kind: hsm.HistoryKindNormal, Duration: time.Second},
+			{Time: start.Add(5 * time.Second), Source: "b", Target: "b", Kind: hsm.HistoryKindInternal},
+			{Time: start.Add(10 * time.Second), Source: "b", Target: "c", Kind: hsm.HistoryKindNormal, Duration: time.Second},
+		},
+	}
+
+	assert.Equal(t, map[string]time.Duration{
+		"b": 9 * time.Second,
+		"c": 19 * time.Second,
+	}, snapshot.TimeInState(start.Add(30*time.Second)))
+}
+
+// NOTE: this tests uses nil-context-machine example definition
+func TestRestoreHistoryEntries(t *testing.T) {
+	entries := []hsm.HistoryEntry{
+		{Time: time.Now(), Signal: "*nSignal", Source: "n1", Target: "n2", Kind: hsm.HistoryKindNormal},
+	}
+
+	machine, err := hsm.NewBuilder[interface{}]().
+		WithName("nil").
+		StartingAt(n1).
+		WithErrorState(hsm.NewErrorState[interface{}]().WithID("error").Build()).
+		AddState(n1).
+		AddState(n2).
+		Restore(hsm.Snapshot{StateID: "n2", Final: true, History: entries})
+
+	require.NoError(t, err)
+	assert.Equal(t, entries, machine.Snapshot().History)
+}
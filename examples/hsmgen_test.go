@@ -0,0 +1,86 @@
+package examples_test
+
+import (
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/botchris/go-hsm/examples/generated/parking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedMachine(t *testing.T) {
+	t.Run("WHEN a valid ticket is given THEN the barrier opens and finishes once passed", func(t *testing.T) {
+		logic := &parkingLogic{valid: true}
+		machine, err := prepareParkingMachine(logic)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&parking.TickSignal{}))
+		assert.Equal(t, parking.ParkingClosedID, machine.Current().ID())
+		assert.Equal(t, 1, logic.ticks)
+
+		require.NoError(t, machine.Signal(&parking.TicketSignal{}))
+		assert.Equal(t, parking.ParkingRaisingID, machine.Current().ID())
+
+		require.NoError(t, machine.Signal(&parking.PassedSignal{}))
+		assert.Equal(t, parking.ParkingOpenFinalID, machine.Current().ID())
+		assert.Equal(t, []string{"lower", "light", "count", "raise"}, logic.calls)
+	})
+
+	t.Run("WHEN an invalid ticket is given THEN the barrier stays closed", func(t *testing.T) {
+		logic := &parkingLogic{}
+		machine, err := prepareParkingMachine(logic)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&parking.TickSignal{}))
+		require.NoError(t, machine.Signal(&parking.TicketSignal{}))
+		assert.Equal(t, parking.ParkingClosedID, machine.Current().ID())
+		assert.Equal(t, []string{"lower", "light", "count", "lower", "light"}, logic.calls)
+	})
+
+	t.Run("WHEN printing the generated machine THEN labels match the diagram", func(t *testing.T) {
+		machine, err := prepareParkingMachine(&parkingLogic{})
+		require.NoError(t, err)
+
+		out := string(hsm.NewPlantUMLPrinter[*parkingLogic]().Print(machine))
+
+		assert.Contains(t, out, "entry / lower; light")
+		assert.Contains(t, out, "[valid]")
+		assert.Contains(t, out, "/ raise")
+	})
+}
+
+// parkingLogic implements the generated parking.ParkingLogic, being its own context.
+type parkingLogic struct {
+	valid bool
+	ticks int
+	calls []string
+}
+
+func (l *parkingLogic) Valid(*parkingLogic) bool { return l.valid }
+
+func (l *parkingLogic) Alarm(*parkingLogic, hsm.Signal) error { return l.call("alarm") }
+
+func (l *parkingLogic) Count(*parkingLogic, hsm.Signal) error {
+	l.ticks++
+
+	return l.call("count")
+}
+
+func (l *parkingLogic) Light(*parkingLogic, hsm.Signal) error { return l.call("light") }
+
+func (l *parkingLogic) Lower(*parkingLogic, hsm.Signal) error { return l.call("lower") }
+
+func (l *parkingLogic) Raise(*parkingLogic, hsm.Signal) error { return l.call("raise") }
+
+func (l *parkingLogic) call(name string) error {
+	l.calls = append(l.calls, name)
+
+	return nil
+}
+
+func prepareParkingMachine(logic *parkingLogic) (*hsm.HSM[*parkingLogic], error) {
+	return parking.NewParkingBuilder[*parkingLogic](logic).
+		WithContext(logic).
+		Build()
+}
@@ -0,0 +1,117 @@
+package examples_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospection(t *testing.T) {
+	t.Run("WHEN listing states THEN every vertex is returned sorted by ID", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		var ids []string
+		for _, s := range machine.States() {
+			ids = append(ids, s.ID())
+		}
+
+		assert.Equal(t, []string{"alarm", "booth", "error", "locked", "unlocked"}, ids)
+	})
+
+	t.Run("WHEN walking the hierarchy THEN parents and children are linked", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		states := statesByID(machine)
+		booth := states[boothID]
+
+		assert.Nil(t, booth.Parent())
+		assert.Equal(t, hsm.VertexKindState, booth.Kind())
+		assert.Equal(t, hsm.VertexKindError, states["error"].Kind())
+		assert.Equal(t, "error", states["error"].Kind().String())
+
+		require.Len(t, booth.Children(), 2)
+		assert.Equal(t, turnstileLockedID, booth.Children()[0].ID())
+		assert.Equal(t, turnstileUnlockedID, booth.Children()[1].ID())
+		assert.Equal(t, booth, booth.Children()[0].Parent())
+		assert.Nil(t, booth.EntryState())
+
+		// vertices given to the builder are never wired
+		assert.Empty(t, boothState.Children())
+	})
+
+	t.Run("WHEN building states from compiled vertices THEN the definition is not modified", func(t *testing.T) {
+		machine, err := prepareOvenMachine(&ovenContext{})
+		require.NoError(t, err)
+
+		states := statesByID(machine)
+		entry := states[heatingEntryID]
+
+		adopter := hsm.NewState[*ovenContext]().
+			WithID("reheating").
+			WithEntryState(entry).
+			Build()
+
+		assert.Equal(t, states[heatingID], entry.Parent())
+		assert.Equal(t, heatingEntryID, adopter.EntryState().ID())
+		assert.Equal(t, adopter, adopter.EntryState().Parent())
+
+		fresh := machine.Definition().New(&ovenContext{})
+		require.NoError(t, fresh.Signal(&doorClosed{}))
+		assert.Equal(t, toastingID, fresh.Snapshot().StateID)
+		assert.Equal(t, []string{doorOpenID, heatingEntryID, toastingID}, fresh.Snapshot().StatesHistory)
+	})
+
+	t.Run("WHEN walking transitions THEN their parts are described", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		locked := statesByID(machine)[turnstileLockedID]
+		transitions := locked.Transitions()
+		require.Len(t, transitions, 2)
+
+		coin, kick := transitions[0], transitions[1]
+
+		assert.Equal(t, "coin", coin.Signal())
+		assert.Equal(t, hsm.TransitionKindNormal, coin.Kind())
+		assert.Equal(t, turnstileUnlockedID, coin.Target().ID())
+		assert.Empty(t, coin.GuardLabel())
+		assert.Empty(t, coin.EffectLabel())
+
+		assert.Equal(t, "kick", kick.Signal())
+		assert.Equal(t, hsm.TransitionKindInternal, kick.Kind())
+		assert.Equal(t, turnstileLockedID, kick.Target().ID())
+		assert.Equal(t, "count()", kick.EffectLabel())
+
+		booth := statesByID(machine)[boothID]
+		assert.Equal(t, "alarm", booth.Transitions()[0].Signal())
+	})
+
+	t.Run("WHEN writing a custom printer THEN the whole graph can be walked", func(t *testing.T) {
+		machine, err := prepareFlagMachine(&flagContext{}, hsm.GuardErrorAbort)
+		require.NoError(t, err)
+
+		var lines []string
+
+		for _, s := range machine.States() {
+			for _, tr := range s.Transitions() {
+				lines = append(lines, fmt.Sprintf("%s -> %s : %s [%s]", s.ID(), tr.Target().ID(), tr.Signal(), tr.GuardLabel()))
+			}
+		}
+
+		assert.Equal(t, []string{"off -> on : *flagSignal [flag enabled]"}, lines)
+	})
+}
+
+func statesByID[C any](machine *hsm.HSM[C]) map[string]*hsm.Vertex[C] {
+	out := make(map[string]*hsm.Vertex[C])
+	for _, s := range machine.States() {
+		out[s.ID()] = s
+	}
+
+	return out
+}
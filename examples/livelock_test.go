@@ -0,0 +1,103 @@
+package examples_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLivelock(t *testing.T) {
+	t.Run("WHEN states loop through signal-less transitions THEN the machine fails with the cycle", func(t *testing.T) {
+		machine, err := prepareFlawedBuilder().WithContext(&flawContext{}).Build()
+		require.NoError(t, err)
+
+		err = machine.Signal(&flawSignal{})
+
+		var livelock *hsm.ErrLivelock
+		require.True(t, errors.As(err, &livelock))
+		assert.Equal(t, "flawed", livelock.Machine)
+		assert.Equal(t, hsm.DefaultMaxMicrosteps, livelock.Microsteps)
+		assert.Equal(t, []string{"busy", "spin", "busy"}, livelock.Cycle)
+		assert.EqualError(t, err, "livelock detected after 100 microsteps through `busy` -> `spin` -> `busy`, hsm `flawed`")
+
+		assert.True(t, machine.Failed())
+		assert.Equal(t, "error", machine.Current().ID())
+	})
+
+	t.Run("WHEN the limit is lowered THEN the livelock is detected sooner", func(t *testing.T) {
+		machine, err := prepareFlawedBuilder().WithContext(&flawContext{}).WithMaxMicrosteps(5).Build()
+		require.NoError(t, err)
+
+		var livelock *hsm.ErrLivelock
+		require.True(t, errors.As(machine.Signal(&flawSignal{}), &livelock))
+		assert.Equal(t, 5, livelock.Microsteps)
+		assert.Equal(t, []string{"spin", "busy", "spin"}, livelock.Cycle)
+	})
+
+	t.Run("WHEN a guarded loop needs more steps than allowed THEN the limit can be raised", func(t *testing.T) {
+		context := &loopContext{}
+		machine, err := prepareLoopMachine(context, hsm.DefaultMaxMicrosteps)
+		require.NoError(t, err)
+
+		var livelock *hsm.ErrLivelock
+		require.True(t, errors.As(machine.Signal(&loopSignal{}), &livelock))
+		assert.Equal(t, []string{"counting", "counting"}, livelock.Cycle)
+
+		context = &loopContext{}
+		machine, err = prepareLoopMachine(context, 200)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&loopSignal{}))
+		assert.Equal(t, "counted", machine.Current().ID())
+		assert.Equal(t, 150, context.count)
+	})
+}
+
+func prepareLoopMachine(context *loopContext, microsteps int) (*hsm.HSM[*loopContext], error) {
+	return hsm.NewBuilder[*loopContext]().
+		WithName("loop").
+		WithContext(context).
+		WithMaxMicrosteps(microsteps).
+		StartingAt(loopIdle).
+		WithErrorState(hsm.NewErrorState[*loopContext]().WithID("error").Build()).
+		AddStates(loopIdle, loopCounting, loopCounted).
+		Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	loopContext struct {
+		count int
+	}
+	loopSignal struct{}
+)
+
+// MACHINE PARTS.
+var loopIdle = hsm.NewState[*loopContext]().
+	WithID("idle").
+	AddTransitions(hsm.NewTransition[*loopContext]().When(&loopSignal{}).GoTo("counting").Build()).
+	Build()
+
+// counting goes back to itself until 150 is reached
+var loopCounting = hsm.NewState[*loopContext]().
+	WithID("counting").
+	AddTransitions(
+		hsm.NewTransition[*loopContext]().
+			GuardedBy(hsm.NewGuard[*loopContext]().WithLabel("below 150").WithMethod(func(ctx *loopContext) bool { return ctx.count < 150 }).Build()).
+			ApplyEffect(hsm.NewEffect[*loopContext]().WithLabel("count++").WithMethod(func(ctx *loopContext, _ hsm.Signal) error {
+				ctx.count++
+
+				return nil
+			}).Build()).
+			GoTo("counting").
+			Build(),
+		hsm.NewTransition[*loopContext]().GoTo("counted").Build(),
+	).
+	Build()
+
+var loopCounted = hsm.NewFinalState[*loopContext]().
+	WithID("counted").
+	Build()
@@ -0,0 +1,86 @@
+package examples_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("WHEN a signal is rejected THEN it is logged", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		machine, err := prepareLoggedDoorMachine(hsm.NewStdLogger(log.New(buf, "", 0), hsm.LogLevelInfo))
+
+		require.NoError(t, err)
+		assert.Error(t, machine.Signal(&keysSignal{}))
+		assert.Equal(t, "level=info msg=\"signal rejected\" hsm=door state=open signal=*keysSignal\n", buf.String())
+	})
+
+	t.Run("WHEN level is below threshold THEN nothing is logged", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		machine, err := prepareLoggedDoorMachine(hsm.NewStdLogger(log.New(buf, "", 0), hsm.LogLevelWarn))
+
+		require.NoError(t, err)
+		assert.Error(t, machine.Signal(&keysSignal{}))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("WHEN error state entry fails THEN it is logged", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		machine, err := hsm.NewBuilder[*errorContext]().
+			WithName("error").
+			WithContext(&errorContext{
+				onExitA: func() error {
+					return fmt.Errorf("dummy error, exit A")
+				},
+			}).
+			WithLogger(hsm.NewStdLogger(log.New(buf, "", 0), hsm.LogLevelDebug)).
+			StartingAt(stateA).
+			WithErrorState(
+				hsm.NewErrorState[*errorContext]().
+					WithID("error").
+					OnEntry(
+						hsm.NewAction[*errorContext]().
+							WithLabel("fail()").
+							WithMethod(func(ctx *errorContext, signal hsm.Signal) error {
+								return fmt.Errorf("dummy error, enter error")
+							}).
+							Build(),
+					).
+					Build()).
+			AddState(stateA).
+			AddState(stateB).
+			Build()
+
+		require.NoError(t, err)
+		assert.Error(t, machine.Signal(&dummySignal{}))
+		assert.True(t, machine.Failed())
+		assert.Equal(t, ""+
+			"level=error msg=\"entering error state\" hsm=error state=A signal=*dummySignal error=\"dummy error, exit A\"\n"+
+			"level=error msg=\"error while entering error state\" hsm=error error=\"dummy error, enter error\"\n",
+			buf.String())
+	})
+}
+
+func prepareLoggedDoorMachine(logger hsm.Logger) (*hsm.HSM[*doorContext], error) {
+	return hsm.NewBuilder[*doorContext]().
+		// meta
+		WithName("door").
+		WithContext(&doorContext{}).
+		WithLogger(logger).
+		StartingAt(openState).
+		WithErrorState(hsm.NewErrorState[*doorContext]().WithID("error").Build()).
+
+		// states
+		AddState(openState).
+		AddState(closedState).
+		AddState(lockedState).
+
+		// build
+		Build()
+}
@@ -0,0 +1,225 @@
+package examples_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchers(t *testing.T) {
+	t.Run("WHEN sending named signals THEN they are matched by value", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		assert.True(t, machine.Can(hsm.Named("coin")))
+		assert.False(t, machine.Can(hsm.Named("push")))
+		assert.Error(t, machine.Signal(hsm.Named("push")))
+
+		require.NoError(t, machine.Signal(hsm.Named("coin")))
+		assert.True(t, machine.At(turnstileUnlockedState))
+
+		require.NoError(t, machine.Signal(hsm.Named("push")))
+		assert.True(t, machine.At(turnstileLockedState))
+		assert.Equal(t, []string{"coin", "push"}, machine.Snapshot().SignalsHistory)
+	})
+
+	t.Run("WHEN sending enum signals THEN they are matched by value", func(t *testing.T) {
+		context := &turnstileContext{}
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		machine, err = machine.Definition().Restore(context, machine.Snapshot())
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(kickCommand))
+		require.NoError(t, machine.Signal(kickCommand))
+		err = machine.Signal(rebootCommand)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signal `reboot`")
+
+		assert.True(t, machine.At(turnstileLockedState))
+		assert.Equal(t, 2, context.kicks)
+		assert.Equal(t, []string{"kick", "kick"}, machine.Snapshot().SignalsHistory)
+	})
+
+	t.Run("WHEN sending enum signals of the same type THEN they are named by value", func(t *testing.T) {
+		machine, err := prepareGateMachine()
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(openGate))
+		assert.EqualError(t, machine.Signal(openGate),
+			"no transition was found from state `opened` and signal `gateEvent(0)`, hsm `gate`")
+		require.NoError(t, machine.Signal(closeGate))
+
+		assert.Equal(t, []string{"gateEvent(0)", "gateEvent(1)"}, machine.Snapshot().SignalsHistory)
+		assert.Equal(t, "gateEvent(1)", machine.Snapshot().History[1].Signal)
+	})
+
+	t.Run("WHEN sending a signal implementing a matched interface THEN it is matched from children states", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&fireAlarm{}))
+		assert.True(t, machine.At(alarmState))
+	})
+
+	t.Run("WHEN sending a signal matched by a predicate THEN transition is taken", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		assert.Error(t, machine.Signal(hsm.Named("maintenance")))
+
+		require.NoError(t, machine.Signal(hsm.Named("service:maintenance")))
+		assert.True(t, machine.At(alarmState))
+	})
+
+	t.Run("WHEN printing THEN matcher labels are used", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		out := string(hsm.NewPlantUMLPrinter[*turnstileContext]().Print(machine))
+
+		for _, label := range []string{": coin", ": push", ">kick ", ": alarm", ": service:*"} {
+			assert.True(t, strings.Contains(out, label), "missing label `%s` in:\n%s", label, out)
+		}
+	})
+}
+
+func prepareGateMachine() (*hsm.HSM[*turnstileContext], error) {
+	closed := hsm.NewState[*turnstileContext]().
+		WithID("closed").
+		AddTransitions(hsm.NewTransition[*turnstileContext]().WhenMatches(hsm.MatchValue(openGate)).GoTo("opened").Build()).
+		Build()
+
+	opened := hsm.NewState[*turnstileContext]().
+		WithID("opened").
+		AddTransitions(hsm.NewTransition[*turnstileContext]().WhenMatches(hsm.MatchValue(closeGate)).GoTo("closed").Build()).
+		Build()
+
+	return hsm.NewBuilder[*turnstileContext]().
+		WithName("gate").
+		WithContext(&turnstileContext{}).
+		StartingAt(closed).
+		WithErrorState(hsm.NewErrorState[*turnstileContext]().WithID("error").Build()).
+		AddStates(closed, opened).
+		Build()
+}
+
+func prepareTurnstileMachine() (*hsm.HSM[*turnstileContext], error) {
+	return hsm.NewBuilder[*turnstileContext]().
+		// meta
+		WithName("turnstile").
+		WithContext(&turnstileContext{}).
+		StartingAt(turnstileLockedState).
+		WithErrorState(hsm.NewErrorState[*turnstileContext]().WithID("error").Build()).
+
+		// states
+		AddState(boothState).
+		AddState(turnstileLockedState).
+		AddState(turnstileUnlockedState).
+		AddState(alarmState).
+
+		// build
+		Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	turnstileContext struct {
+		kicks int
+	}
+
+	turnstileCommand int
+	gateEvent        int
+	alarm            interface{ alarm() }
+	fireAlarm        struct{}
+)
+
+const (
+	rebootCommand turnstileCommand = iota
+	kickCommand
+)
+
+const (
+	openGate gateEvent = iota
+	closeGate
+)
+
+func (c turnstileCommand) String() string {
+	if c == kickCommand {
+		return "kick"
+	}
+
+	return "reboot"
+}
+
+func (fireAlarm) alarm() {}
+
+// STATE IDS.
+var (
+	boothID             = "booth"
+	turnstileLockedID   = "locked"
+	turnstileUnlockedID = "unlocked"
+	alarmID             = "alarm"
+)
+
+// MACHINE PARTS.
+var boothState = hsm.NewState[*turnstileContext]().
+	WithID(boothID).
+	AddTransitions(
+		hsm.NewTransition[*turnstileContext]().
+			WhenMatches(hsm.MatchInterface[alarm]()).
+			GoTo(alarmID).
+			Build(),
+		hsm.NewTransition[*turnstileContext]().
+			WhenMatches(hsm.MatchFunc("service:*", func(signal hsm.Signal) bool {
+				name, ok := signal.(hsm.Named)
+
+				return ok && strings.HasPrefix(string(name), "service:")
+			})).
+			GoTo(alarmID).
+			Build(),
+	).
+	Build()
+
+var turnstileLockedState = hsm.NewState[*turnstileContext]().
+	WithID(turnstileLockedID).
+	ParentOf(boothState).
+	AddTransitions(
+		hsm.NewTransition[*turnstileContext]().
+			When(hsm.Named("coin")).
+			GoTo(turnstileUnlockedID).
+			Build(),
+		hsm.NewInternalTransition[*turnstileContext]().
+			WhenMatches(hsm.MatchValue(kickCommand)).
+			ApplyEffect(
+				hsm.NewEffect[*turnstileContext]().
+					WithLabel("count()").
+					WithMethod(func(ctx *turnstileContext, signal hsm.Signal) error {
+						ctx.kicks++
+
+						return nil
+					}).
+					Build(),
+			).
+			Build(),
+	).
+	Build()
+
+var turnstileUnlockedState = hsm.NewState[*turnstileContext]().
+	WithID(turnstileUnlockedID).
+	ParentOf(boothState).
+	AddTransitions(
+		hsm.NewTransition[*turnstileContext]().
+			When(hsm.Named("push")).
+			GoTo(turnstileLockedID).
+			Build(),
+	).
+	Build()
+
+var alarmState = hsm.NewState[*turnstileContext]().
+	WithID(alarmID).
+	Build()
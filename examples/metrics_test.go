@@ -0,0 +1,67 @@
+package examples_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := hsm.NewMetricsWithBuckets([]float64{1}, []float64{60})
+	machine, err := hsm.NewBuilder[*doorContext]().
+		// meta
+		WithName("door").
+		WithContext(&doorContext{}).
+		WithMetrics(metrics).
+		StartingAt(openState).
+		WithErrorState(hsm.NewErrorState[*doorContext]().WithID("error").Build()).
+
+		// states
+		AddState(openState).
+		AddState(closedState).
+		AddState(lockedState).
+
+		// build
+		Build()
+
+	require.NoError(t, err)
+	assert.Error(t, machine.Signal(&keysSignal{}))
+	assert.NoError(t, machine.Signal(&handleSignal{}))
+	assert.NoError(t, machine.Signal(&keysSignal{}))
+
+	t.Run("WHEN written as prometheus text THEN samples are exposed", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, metrics.WritePrometheus(buf))
+
+		out := buf.String()
+		for _, line := range []string{
+			"# TYPE hsm_signals_accepted_total counter",
+			`hsm_signals_accepted_total{machine="door",signal="*handleSignal"} 1`,
+			`hsm_signals_accepted_total{machine="door",signal="*keysSignal"} 1`,
+			`hsm_signals_rejected_total{machine="door",signal="*keysSignal"} 1`,
+			`hsm_transitions_total{machine="door",source="open",target="closed"} 1`,
+			`hsm_transitions_total{machine="door",source="closed",target="locked"} 1`,
+			"# TYPE hsm_action_duration_seconds histogram",
+			`hsm_action_duration_seconds_bucket{machine="door",phase="exit",le="1"} 2`,
+			`hsm_action_duration_seconds_bucket{machine="door",phase="exit",le="+Inf"} 2`,
+			`hsm_action_duration_seconds_count{machine="door",phase="effect"} 1`,
+			`hsm_state_duration_seconds_count{machine="door",state="open"} 1`,
+		} {
+			assert.Contains(t, strings.Split(out, "\n"), line)
+		}
+
+		assert.NotContains(t, out, "hsm_errors_total{")
+	})
+
+	t.Run("WHEN exported through expvar THEN output is valid JSON", func(t *testing.T) {
+		out := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(metrics.String()), &out))
+		assert.Contains(t, out, "hsm_signals_accepted_total")
+		assert.Contains(t, out, "hsm_state_duration_seconds")
+	})
+}
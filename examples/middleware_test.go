@@ -0,0 +1,103 @@
+package examples_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("WHEN middlewares are registered THEN every dispatch goes through them in order", func(t *testing.T) {
+		var calls []string
+
+		tracing := func(name string) hsm.Middleware[*choiceCtx] {
+			return func(next hsm.Dispatcher[*choiceCtx]) hsm.Dispatcher[*choiceCtx] {
+				return func(h *hsm.HSM[*choiceCtx], d hsm.Dispatch) error {
+					calls = append(calls, fmt.Sprintf("%s(%T, completion=%t)", name, d.Signal, d.Completion))
+
+					return next(h, d)
+				}
+			}
+		}
+
+		machine, err := prepareMiddlewareChoiceMachine(&choiceCtx{g3: true}, tracing("outer"), tracing("inner"))
+
+		require.NoError(t, err)
+		require.NoError(t, machine.Signal(&choiceSignal{}))
+		assert.True(t, machine.At(c3))
+
+		assert.Equal(t, []string{
+			"outer(<nil>, completion=true)",
+			"inner(<nil>, completion=true)",
+			"outer(*examples_test.choiceSignal, completion=false)",
+			"inner(*examples_test.choiceSignal, completion=false)",
+			"outer(<nil>, completion=true)",
+			"inner(<nil>, completion=true)",
+		}, calls)
+	})
+
+	t.Run("WHEN middleware short-circuits THEN signal is not applied", func(t *testing.T) {
+		deny := func(next hsm.Dispatcher[*choiceCtx]) hsm.Dispatcher[*choiceCtx] {
+			return func(h *hsm.HSM[*choiceCtx], d hsm.Dispatch) error {
+				if !d.Completion {
+					return fmt.Errorf("unauthorized signal %T", d.Signal)
+				}
+
+				return next(h, d)
+			}
+		}
+
+		machine, err := prepareMiddlewareChoiceMachine(&choiceCtx{g3: true}, deny)
+
+		require.NoError(t, err)
+		assert.Error(t, machine.Signal(&choiceSignal{}))
+		assert.True(t, machine.At(c1))
+		assert.False(t, machine.Failed())
+	})
+
+	t.Run("WHEN middleware rewrites the signal THEN rewritten signal is applied", func(t *testing.T) {
+		rewrite := func(next hsm.Dispatcher[*choiceCtx]) hsm.Dispatcher[*choiceCtx] {
+			return func(h *hsm.HSM[*choiceCtx], d hsm.Dispatch) error {
+				if _, ok := d.Signal.(*legacyChoiceSignal); ok {
+					d.Signal = &choiceSignal{}
+				}
+
+				return next(h, d)
+			}
+		}
+
+		machine, err := prepareMiddlewareChoiceMachine(&choiceCtx{g4: true}, rewrite)
+
+		require.NoError(t, err)
+		require.NoError(t, machine.Signal(&legacyChoiceSignal{}))
+		assert.True(t, machine.At(c4))
+	})
+}
+
+func prepareMiddlewareChoiceMachine(context *choiceCtx, middlewares ...hsm.Middleware[*choiceCtx]) (*hsm.HSM[*choiceCtx], error) {
+	return hsm.NewBuilder[*choiceCtx]().
+		// meta
+		WithName("choice").
+		WithContext(context).
+		StartingAt(c0).
+		WithErrorState(hsm.NewErrorState[*choiceCtx]().WithID("error").Build()).
+		Use(middlewares...).
+
+		// states
+		AddState(c0).
+		AddState(c1).
+		AddState(c2).
+		AddState(c3).
+		AddState(c4).
+		AddState(c5).
+		AddState(final).
+
+		// build
+		Build()
+}
+
+// SIGNALS
+type legacyChoiceSignal struct{}
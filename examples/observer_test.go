@@ -0,0 +1,156 @@
+package examples_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+	t.Run("WHEN a signal is applied THEN hooks are called in order", func(t *testing.T) {
+		observer := &recordingObserver{}
+		machine, err := hsm.NewBuilder[*doorContext]().
+			// meta
+			WithName("door").
+			WithContext(&doorContext{}).
+			StartingAt(openState).
+			WithErrorState(hsm.NewErrorState[*doorContext]().WithID("error").Build()).
+			WithObserver(observer).
+
+			// states
+			AddState(openState).
+			AddState(closedState).
+			AddState(lockedState).
+
+			// build
+			Build()
+
+		require.NoError(t, err)
+		require.NoError(t, machine.Signal(&handleSignal{}))
+
+		assert.Equal(t, []string{
+			"received *handleSignal at open",
+			"exit open",
+			"effect open->closed",
+			"enter closed",
+			"complete open->closed",
+		}, observer.calls)
+
+		for _, e := range observer.events {
+			assert.Equal(t, "door", e.Machine)
+			assert.False(t, e.Time.IsZero())
+		}
+	})
+
+	t.Run("WHEN a signal is rejected THEN rejection is notified", func(t *testing.T) {
+		observer := &recordingObserver{}
+		machine, err := prepareDoorMachine(&doorContext{})
+
+		require.NoError(t, err)
+		machine.AddObserver(observer)
+
+		assert.Error(t, machine.Signal(&keysSignal{}))
+		assert.Equal(t, []string{
+			"received *keysSignal at open",
+			"rejected *keysSignal at open",
+		}, observer.calls)
+
+		machine.RemoveObserver(observer)
+		assert.NoError(t, machine.Signal(&handleSignal{}))
+		assert.Len(t, observer.calls, 2)
+	})
+
+	t.Run("WHEN a step fails THEN error is notified", func(t *testing.T) {
+		observer := &recordingObserver{}
+		machine, err := prepareErrorMachine(&errorContext{
+			onExitA: func() error {
+				return nil
+			},
+			transitionFx: func() error {
+				return fmt.Errorf("dummy error, transition FX")
+			},
+			onEntryB: func() error {
+				return nil
+			},
+		})
+
+		require.NoError(t, err)
+		machine.AddObserver(observer)
+
+		assert.Error(t, machine.Signal(&dummySignal{}))
+		assert.Equal(t, []string{
+			"received *dummySignal at A",
+			"exit A",
+			"error effect A->B: dummy error, transition FX",
+		}, observer.calls)
+	})
+
+	t.Run("WHEN an observer is added while a signal is processed THEN it is notified from the next signal on", func(t *testing.T) {
+		machine, err := prepareDoorMachine(&doorContext{})
+		require.NoError(t, err)
+
+		late := &recordingObserver{}
+		machine.AddObserver(&joiningObserver{join: func() { machine.AddObserver(late) }})
+
+		require.NoError(t, machine.Signal(&handleSignal{}))
+		assert.Empty(t, late.calls)
+
+		require.NoError(t, machine.Signal(&handleSignal{}))
+		assert.Equal(t, "received *handleSignal at closed", late.calls[0])
+	})
+}
+
+// joiningObserver calls join when the first vertex is exited.
+type joiningObserver struct {
+	hsm.NopObserver
+	join func()
+}
+
+func (o *joiningObserver) OnExit(hsm.Event) {
+	if o.join != nil {
+		o.join()
+		o.join = nil
+	}
+}
+
+// recordingObserver keeps a human-readable log of every notification.
+type recordingObserver struct {
+	calls  []string
+	events []hsm.Event
+}
+
+func (o *recordingObserver) record(e hsm.Event, format string, args ...interface{}) {
+	o.calls = append(o.calls, fmt.Sprintf(format, args...))
+	o.events = append(o.events, e)
+}
+
+func (o *recordingObserver) OnSignalReceived(e hsm.Event) {
+	o.record(e, "received %s at %s", e.SignalKind, e.Source)
+}
+
+func (o *recordingObserver) OnSignalRejected(e hsm.Event) {
+	o.record(e, "rejected %s at %s", e.SignalKind, e.Source)
+}
+
+func (o *recordingObserver) OnExit(e hsm.Event) {
+	o.record(e, "exit %s", e.Vertex)
+}
+
+func (o *recordingObserver) OnEffect(e hsm.Event) {
+	o.record(e, "effect %s->%s", e.Source, e.Target)
+}
+
+func (o *recordingObserver) OnEnter(e hsm.Event) {
+	o.record(e, "enter %s", e.Vertex)
+}
+
+func (o *recordingObserver) OnTransitionComplete(e hsm.Event) {
+	o.record(e, "complete %s->%s", e.Source, e.Target)
+}
+
+func (o *recordingObserver) OnError(e hsm.Event) {
+	o.record(e, "error %s %s->%s: %s", e.Phase, e.Source, e.Target, e.Err)
+}
@@ -0,0 +1,269 @@
+package examples_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionParser(t *testing.T) {
+	t.Run("WHEN loading a YAML definition THEN the machine runs registered logic", func(t *testing.T) {
+		context := &barrierContext{valid: true}
+		machine, err := prepareBarrierMachine(context, hsm.ParseYAML[*barrierContext], barrierYAML)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&barrierTicket{}))
+		assert.Equal(t, barrierRaisingID, machine.Current().ID())
+		assert.Equal(t, []string{"lower", "light", "raise"}, context.calls)
+
+		require.NoError(t, machine.Signal(hsm.Named("passed")))
+		assert.True(t, machine.Finished())
+	})
+
+	t.Run("WHEN a choice guard does not hold THEN the else branch is taken", func(t *testing.T) {
+		context := &barrierContext{}
+		machine, err := prepareBarrierMachine(context, hsm.ParseYAML[*barrierContext], barrierYAML)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&barrierTicket{}))
+		assert.Equal(t, barrierClosedID, machine.Current().ID())
+
+		require.NoError(t, machine.Signal(hsm.Named("tick")))
+		assert.Equal(t, barrierClosedID, machine.Current().ID())
+		assert.Equal(t, 1, context.ticks)
+	})
+
+	t.Run("WHEN loading the same definition from JSON THEN both machines are equal", func(t *testing.T) {
+		fromYAML, err := prepareBarrierMachine(&barrierContext{}, hsm.ParseYAML[*barrierContext], barrierYAML)
+		require.NoError(t, err)
+
+		fromJSON, err := prepareBarrierMachine(&barrierContext{}, hsm.ParseJSON[*barrierContext], barrierJSON)
+		require.NoError(t, err)
+
+		printer := hsm.NewMermaidPrinter[*barrierContext]()
+		assert.Equal(t, string(printer.Print(fromYAML)), string(printer.Print(fromJSON)))
+
+		states := statesByID(fromJSON)
+		assert.Equal(t, hsm.VertexKindChoice, states[barrierCheckingID].Kind())
+		assert.Equal(t, states[barrierOpenID], states[barrierRaisingID].Parent())
+		assert.Equal(t, "openEntry", states[barrierOpenID].EntryState().ID())
+		assert.Equal(t, hsm.TransitionKindInternal, states[barrierClosedID].Transitions()[1].Kind())
+		assert.Equal(t, "count", states[barrierClosedID].Transitions()[1].EffectLabel())
+	})
+
+	t.Run("WHEN loading broken definitions THEN errors point at line and path", func(t *testing.T) {
+		cases := []struct {
+			document string
+			line     int
+			path     string
+			message  string
+		}{
+			{
+				document: strings.Replace(barrierYAML, "guard: valid", "guard: bogus", 1),
+				line:     22,
+				path:     "$.states[2].transitions[0].guard",
+				message:  "guard `bogus` is not registered",
+			},
+			{
+				document: strings.Replace(barrierYAML, "effect: raise", "effect: [raise, dance]", 1),
+				line:     34,
+				path:     "$.states[4].transitions[0].effect[1]",
+				message:  "action `dance` is not registered",
+			},
+			{
+				document: strings.Replace(barrierYAML, "signal: ticket", "signal: coin", 1),
+				line:     14,
+				path:     "$.states[1].transitions[0].signal",
+				message:  "signal `coin` is not registered",
+			},
+			{
+				document: strings.Replace(barrierYAML, "target: done", "target: nowhere", 1),
+				line:     29,
+				path:     "$.states[3].transitions[0].target",
+				message:  "state `nowhere` not found",
+			},
+			{
+				document: strings.Replace(barrierYAML, "kind: final", "kind: final\n    onExit: lower", 1),
+				line:     39,
+				path:     "$.states[6].onExit",
+				message:  "field `onExit` is not allowed for final vertices",
+			},
+			{
+				document: strings.Replace(barrierYAML, "    parent: open", "    parnet: open", 1),
+				line:     36,
+				path:     "$.states[5]",
+				message:  "unknown field `parnet`",
+			},
+		}
+
+		for _, c := range cases {
+			_, err := prepareBarrierMachine(&barrierContext{}, hsm.ParseYAML[*barrierContext], c.document)
+
+			var definitionErr *hsm.DefinitionError
+			require.True(t, errors.As(err, &definitionErr), "%v", err)
+			assert.Equal(t, c.line, definitionErr.Line, c.message)
+			assert.Equal(t, c.path, definitionErr.Path)
+			assert.EqualError(t, definitionErr.Err, c.message)
+		}
+	})
+
+	t.Run("WHEN loading malformed JSON THEN the syntax error line is reported", func(t *testing.T) {
+		_, err := prepareBarrierMachine(&barrierContext{}, hsm.ParseJSON[*barrierContext], strings.Replace(barrierJSON, `"closed",`, `"closed"`, 1))
+
+		var definitionErr *hsm.DefinitionError
+		require.True(t, errors.As(err, &definitionErr), "%v", err)
+		assert.Equal(t, 9, definitionErr.Line)
+	})
+
+	t.Run("WHEN reading the published schema THEN it describes every vertex kind", func(t *testing.T) {
+		var schema struct {
+			Required []string `json:"required"`
+			Defs     struct {
+				Vertex struct {
+					Properties struct {
+						Kind struct {
+							Enum []string `json:"enum"`
+						} `json:"kind"`
+					} `json:"properties"`
+				} `json:"vertex"`
+			} `json:"$defs"`
+		}
+
+		require.NoError(t, json.Unmarshal(hsm.DefinitionSchema, &schema))
+		assert.Equal(t, []string{"name", "start", "error", "states"}, schema.Required)
+		assert.ElementsMatch(t, []string{
+			hsm.VertexKindState.String(),
+			hsm.VertexKindStart.String(),
+			hsm.VertexKindChoice.String(),
+			hsm.VertexKindEntry.String(),
+			hsm.VertexKindFinal.String(),
+		}, schema.Defs.Vertex.Properties.Kind.Enum)
+	})
+}
+
+func prepareBarrierMachine(
+	context *barrierContext,
+	parse func(r io.Reader, registry *hsm.Registry[*barrierContext]) (*hsm.Builder[*barrierContext], error),
+	document string,
+) (*hsm.HSM[*barrierContext], error) {
+	registry := hsm.NewRegistry[*barrierContext]().
+		RegisterSignal("ticket", &barrierTicket{}).
+		RegisterSignal("tick", hsm.Named("tick")).
+		RegisterSignal("passed", hsm.Named("passed")).
+		RegisterGuard("valid", func(ctx *barrierContext) bool { return ctx.valid }).
+		RegisterAction("count", func(ctx *barrierContext, signal hsm.Signal) error {
+			ctx.ticks++
+
+			return nil
+		}).
+		RegisterAction("alarm", recordBarrier("alarm")).
+		RegisterAction("lower", recordBarrier("lower")).
+		RegisterAction("light", recordBarrier("light")).
+		RegisterAction("raise", recordBarrier("raise"))
+
+	builder, err := parse(strings.NewReader(document), registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.WithContext(context).Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	barrierContext struct {
+		valid bool
+		ticks int
+		calls []string
+	}
+	barrierTicket struct{}
+)
+
+func recordBarrier(name string) hsm.ActionFunc[*barrierContext] {
+	return func(ctx *barrierContext, signal hsm.Signal) error {
+		ctx.calls = append(ctx.calls, name)
+
+		return nil
+	}
+}
+
+// STATE IDS.
+var (
+	barrierClosedID   = "closed"
+	barrierCheckingID = "checking"
+	barrierOpenID     = "open"
+	barrierRaisingID  = "raising"
+)
+
+// MACHINE PARTS.
+const barrierYAML = `name: barrier
+start: start
+error:
+  id: error
+  onEntry: alarm
+states:
+  - id: start
+    kind: start
+    transitions:
+      - target: closed
+  - id: closed
+    onEntry: [lower, light]
+    transitions:
+      - signal: ticket
+        target: checking
+      - signal: tick
+        kind: internal
+        effect: count
+  - id: checking
+    kind: choice
+    transitions:
+      - guard: valid
+        target: open
+      - target: closed
+  - id: open
+    entry: openEntry
+    transitions:
+      - signal: passed
+        target: done
+  - id: openEntry
+    kind: entry
+    transitions:
+      - target: raising
+        effect: raise
+  - id: raising
+    parent: open
+  - id: done
+    kind: final
+`
+
+const barrierJSON = `{
+  "name": "barrier",
+  "start": "start",
+  "error": {"id": "error", "onEntry": "alarm"},
+  "states": [
+    {"id": "start", "kind": "start", "transitions": [{"target": "closed"}]},
+    {
+      "id": "closed",
+      "onEntry": ["lower", "light"],
+      "transitions": [
+        {"signal": "ticket", "target": "checking"},
+        {"signal": "tick", "kind": "internal", "effect": "count"}
+      ]
+    },
+    {
+      "id": "checking",
+      "kind": "choice",
+      "transitions": [{"guard": "valid", "target": "open"}, {"target": "closed"}]
+    },
+    {"id": "open", "entry": "openEntry", "transitions": [{"signal": "passed", "target": "done"}]},
+    {"id": "openEntry", "kind": "entry", "transitions": [{"target": "raising", "effect": "raise"}]},
+    {"id": "raising", "parent": "open"},
+    {"id": "done", "kind": "final"}
+  ]
+}`
@@ -0,0 +1,147 @@
+package examples_test
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/botchris/go-hsm/examples/generated/parking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlantUMLParser(t *testing.T) {
+	t.Run("WHEN parsing a diagram THEN the model follows its nesting", func(t *testing.T) {
+		file, err := os.Open("generated/parking/parking.puml")
+		require.NoError(t, err)
+
+		defer file.Close()
+
+		model, err := hsm.ParsePlantUML(file)
+		require.NoError(t, err)
+
+		assert.Equal(t, "parking", model.Name)
+		assert.Equal(t, "start", model.Start)
+		assert.Equal(t, hsm.ModelErrorState{ID: "error", OnEntry: hsm.ModelActions{"alarm"}}, model.Error)
+
+		closed := model.Vertex("closed")
+		assert.Equal(t, hsm.ModelActions{"lower", "light"}, closed.OnEntry)
+		assert.Equal(t, []*hsm.ModelTransition{
+			{Signal: "ticket", Target: "checking"},
+			{Signal: "tick", Effect: hsm.ModelActions{"count"}, Kind: "internal"},
+		}, closed.Transitions)
+
+		assert.Equal(t, "choice", model.Vertex("checking").Kind)
+		assert.Equal(t, "open entry", model.Vertex("open").Entry)
+		assert.Equal(t, "open", model.Vertex("raising").Parent)
+		assert.Equal(t, "open", model.Vertex("open final").Parent)
+		assert.Equal(t, hsm.ModelActions{"raise"}, model.Vertex("open entry").Transitions[0].Effect)
+	})
+
+	t.Run("WHEN printing a parsed machine THEN an equivalent diagram is printed", func(t *testing.T) {
+		source, err := prepareParkingMachine(&parkingLogic{})
+		require.NoError(t, err)
+
+		printer := hsm.NewPlantUMLPrinter[*parkingLogic]()
+		diagram := printer.Print(source)
+
+		model, err := hsm.ParsePlantUML(bytes.NewReader(diagram))
+		require.NoError(t, err)
+
+		logic := &parkingLogic{valid: true}
+		registry := hsm.NewRegistry[*parkingLogic]().
+			RegisterSignal("TicketSignal", &parking.TicketSignal{}).
+			RegisterSignal("TickSignal", &parking.TickSignal{}).
+			RegisterSignal("PassedSignal", &parking.PassedSignal{}).
+			RegisterGuard("valid", logic.Valid).
+			RegisterAction("alarm", logic.Alarm).
+			RegisterAction("count", logic.Count).
+			RegisterAction("light", logic.Light).
+			RegisterAction("lower", logic.Lower).
+			RegisterAction("raise", logic.Raise)
+
+		builder, err := hsm.BuildModel(model, registry)
+		require.NoError(t, err)
+
+		machine, err := builder.WithContext(logic).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, plantUMLStatements(diagram), plantUMLStatements(printer.Print(machine)))
+
+		require.NoError(t, machine.Signal(&parking.TicketSignal{}))
+		assert.Equal(t, parking.ParkingRaisingID, machine.Current().ID())
+		assert.Equal(t, []string{"lower", "light", "raise"}, logic.calls)
+	})
+
+	t.Run("WHEN printing a parsed choice THEN an equivalent diagram is printed", func(t *testing.T) {
+		source, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		printer := hsm.NewPlantUMLPrinter[*choiceCtx]()
+		diagram := printer.Print(source)
+
+		model, err := hsm.ParsePlantUML(bytes.NewReader(diagram))
+		require.NoError(t, err)
+
+		// unnamed start states cannot be told apart by diagrams
+		model.Vertex(model.Start).ID = c0ID
+		model.Start = c0ID
+
+		registry := hsm.NewRegistry[*choiceCtx]().
+			RegisterSignal("choiceSignal", &choiceSignal{}).
+			RegisterGuard("g3", func(ctx *choiceCtx) bool { return ctx.g3 }).
+			RegisterGuard("g4", func(ctx *choiceCtx) bool { return ctx.g4 }).
+			RegisterGuard("g5", func(ctx *choiceCtx) bool { return ctx.g5 })
+
+		builder, err := hsm.BuildModel(model, registry)
+		require.NoError(t, err)
+
+		machine, err := builder.WithContext(&choiceCtx{g5: true}).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, plantUMLStatements(diagram), plantUMLStatements(printer.Print(machine)))
+		assert.Equal(t, hsm.VertexKindChoice, statesByID(machine)[c2ID].Kind())
+
+		require.NoError(t, machine.Signal(&choiceSignal{}))
+		assert.Equal(t, c5ID, machine.Current().ID())
+	})
+
+	t.Run("WHEN parsing unsupported or unknown parts THEN errors point at them", func(t *testing.T) {
+		diagram := "@startuml\n[*] --> idle\nstate idle {\nidle --> busy : go [ready]\n}\n@enduml\n"
+
+		_, err := hsm.ParsePlantUML(strings.NewReader(strings.Replace(diagram, "[*] --> idle", "note left of idle", 1)))
+		assert.EqualError(t, err, "plantuml line 2: unsupported statement `note left of idle`")
+
+		_, err = hsm.ParsePlantUML(strings.NewReader(strings.Replace(diagram, "}\n", "", 1)))
+		assert.EqualError(t, err, "plantuml line 5: state `idle` is not closed")
+
+		model, err := hsm.ParsePlantUML(strings.NewReader(diagram))
+		require.NoError(t, err)
+
+		_, err = hsm.BuildModel(model, hsm.NewRegistry[*choiceCtx]())
+		assert.EqualError(t, err, "model $.name: missing name")
+
+		model.Name = "busy"
+		_, err = hsm.BuildModel(model, hsm.NewRegistry[*choiceCtx]().RegisterSignal("go", hsm.Named("go")))
+		assert.EqualError(t, err, "model $.states[1].transitions[0].guard: guard `ready` is not registered")
+	})
+}
+
+// plantUMLStatements returns the sorted statements of the given diagram, as vertices may
+// be printed in a different order once parsed.
+func plantUMLStatements(diagram []byte) []string {
+	var out []string
+
+	for _, line := range strings.Split(string(diagram), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+
+	sort.Strings(out)
+
+	return out
+}
@@ -0,0 +1,163 @@
+package examples_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCXMLParser(t *testing.T) {
+	t.Run("WHEN parsing a document THEN the machine runs registered logic", func(t *testing.T) {
+		context := &vendingContext{}
+		machine, err := prepareVendingMachine(context, vendingDocument)
+		require.NoError(t, err)
+
+		assert.Equal(t, hsm.SCXMLStartID, machine.Current().ID())
+
+		require.NoError(t, machine.Signal(&vendingCoin{}))
+		assert.Equal(t, vendingIdle, machine.Current().ID())
+		assert.Equal(t, []string{"reset"}, context.calls)
+		assert.Equal(t, 1, context.credit)
+
+		// not enough credit
+		assert.False(t, machine.CanWith(hsm.Named("select")))
+
+		require.NoError(t, machine.Signal(&vendingCoin{}))
+		require.NoError(t, machine.Signal(hsm.Named("select")))
+		assert.Equal(t, vendingDropping, machine.Current().ID())
+		assert.Equal(t, []string{"reset", "charge", "light", "beep"}, context.calls)
+
+		require.NoError(t, machine.Signal(hsm.Named("dropped")))
+		assert.Equal(t, vendingDone, machine.Current().ID())
+		assert.True(t, machine.Finished())
+	})
+
+	t.Run("WHEN parsing a compound state THEN its initial state is entered", func(t *testing.T) {
+		machine, err := prepareVendingMachine(&vendingContext{}, vendingDocument)
+		require.NoError(t, err)
+
+		states := statesByID(machine)
+		assert.Equal(t, hsm.VertexKindState, states[vendingServing].Kind())
+		assert.Equal(t, states[vendingServing], states[vendingDropping].Parent())
+		assert.Equal(t, hsm.VertexKindFinal, states[vendingDone].Kind())
+
+		coin := states[vendingIdle].Transitions()[0]
+		assert.Equal(t, hsm.TransitionKindInternal, coin.Kind())
+		assert.Equal(t, "insert", coin.EffectLabel())
+	})
+
+	t.Run("WHEN parsing a printed machine THEN it behaves like the original one", func(t *testing.T) {
+		source, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		registry := hsm.NewRegistry[*choiceCtx]().
+			RegisterSignal("choiceSignal", &choiceSignal{}).
+			RegisterGuard("g3", func(ctx *choiceCtx) bool { return ctx.g3 }).
+			RegisterGuard("g4", func(ctx *choiceCtx) bool { return ctx.g4 }).
+			RegisterGuard("g5", func(ctx *choiceCtx) bool { return ctx.g5 })
+
+		builder, err := hsm.ParseSCXML(bytes.NewReader(hsm.NewSCXMLPrinter[*choiceCtx]().Print(source)), registry)
+		require.NoError(t, err)
+
+		machine, err := builder.
+			WithContext(&choiceCtx{g4: true}).
+			WithErrorState(hsm.NewErrorState[*choiceCtx]().WithID("failure").Build()).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, hsm.VertexKindChoice, statesByID(machine)[c2ID].Kind())
+		require.NoError(t, machine.Signal(&choiceSignal{}))
+		assert.Equal(t, c4ID, machine.Current().ID())
+	})
+
+	t.Run("WHEN parsing unsupported or unknown parts THEN errors point at them", func(t *testing.T) {
+		cases := map[string]string{
+			"scxml line 9: unsupported element <parallel>":                      strings.Replace(vendingDocument, `<state id="serving">`, `<parallel id="p"/><state id="serving">`, 1),
+			"scxml line 4: guard `rich` is not registered":                      strings.Replace(vendingDocument, `cond="paid"`, `cond="rich"`, 1),
+			"scxml line 4: signal `refund` is not registered":                   strings.Replace(vendingDocument, `event="select"`, `event="refund"`, 1),
+			"scxml line 6: action `dance` is not registered":                    strings.Replace(vendingDocument, `<script>reset</script>`, `<script>dance</script>`, 1),
+			"scxml line 6: unsupported executable content <assign>":             strings.Replace(vendingDocument, `<script>reset</script>`, `<assign location="x" expr="1"/>`, 1),
+			"scxml line 1: initial state `nowhere` not found":                   strings.Replace(vendingDocument, `initial="idle"`, `initial="nowhere"`, 1),
+			"scxml line 4: transitions with multiple targets are not supported": strings.Replace(vendingDocument, `target="serving"`, `target="serving done"`, 1),
+		}
+
+		for expected, document := range cases {
+			_, err := prepareVendingMachine(&vendingContext{}, document)
+			assert.EqualError(t, err, expected)
+		}
+	})
+}
+
+func prepareVendingMachine(context *vendingContext, document string) (*hsm.HSM[*vendingContext], error) {
+	registry := hsm.NewRegistry[*vendingContext]().
+		RegisterSignal("coin", &vendingCoin{}).
+		RegisterSignal("select", hsm.Named("select")).
+		RegisterSignal("dropped", hsm.Named("dropped")).
+		RegisterGuard("paid", func(ctx *vendingContext) bool { return ctx.credit >= 2 }).
+		RegisterAction("insert", func(ctx *vendingContext, signal hsm.Signal) error {
+			ctx.credit++
+
+			return nil
+		}).
+		RegisterAction("reset", recordVending("reset")).
+		RegisterAction("charge", recordVending("charge")).
+		RegisterAction("light", recordVending("light")).
+		RegisterAction("beep", recordVending("beep"))
+
+	builder, err := hsm.ParseSCXML(strings.NewReader(document), registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.
+		WithContext(context).
+		WithErrorState(hsm.NewErrorState[*vendingContext]().WithID("error").Build()).
+		Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	vendingContext struct {
+		credit int
+		calls  []string
+	}
+	vendingCoin struct{}
+)
+
+func recordVending(name string) hsm.ActionFunc[*vendingContext] {
+	return func(ctx *vendingContext, signal hsm.Signal) error {
+		ctx.calls = append(ctx.calls, name)
+
+		return nil
+	}
+}
+
+// STATE IDS.
+var (
+	vendingIdle     = "idle"
+	vendingServing  = "serving"
+	vendingDropping = "dropping"
+	vendingDone     = "done"
+)
+
+// MACHINE PARTS.
+const vendingDocument = `<scxml xmlns="http://www.w3.org/2005/07/scxml" version="1.0" name="vending" initial="idle">
+  <state id="idle">
+    <transition event="coin"><script>insert</script></transition>
+    <transition event="select" cond="paid" target="serving"><script>charge</script></transition>
+    <onentry>
+      <script>reset</script>
+    </onentry>
+  </state>
+  <state id="serving">
+    <onentry><script>light</script><send event="beep"/></onentry>
+    <state id="dropping">
+      <transition event="dropped" target="done"/>
+    </state>
+  </state>
+  <final id="done"/>
+</scxml>`
@@ -0,0 +1,54 @@
+package examples_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotPrinter(t *testing.T) {
+	t.Run("WHEN printing a nested machine THEN composite states are rendered as clusters", func(t *testing.T) {
+		machine, err := prepareOrderMachine(&orderContext{})
+		require.NoError(t, err)
+
+		out := string(hsm.NewDotPrinter[*orderContext]().Print(machine))
+
+		assert.True(t, strings.HasPrefix(out, `digraph "order" {`))
+		assert.True(t, strings.HasSuffix(out, "}\n"))
+		assert.Equal(t, strings.Count(out, "{"), strings.Count(out, "}"))
+
+		assert.Contains(t, out, `subgraph "cluster_s" {`)
+		assert.Contains(t, out, `subgraph "cluster_s1" {`)
+		assert.Contains(t, out, `label="s2\nentry / c()";`)
+		assert.Contains(t, out, `"s1" -> "s2" [label="tSignal [g()] / t()", ltail="cluster_s1", lhead="cluster_s2"];`)
+		assert.Contains(t, out, `"error" [style="rounded,filled", color=red`)
+		assert.Contains(t, out, `"s11" [label="s11\nexit / a()", color=blue, penwidth=2];`)
+	})
+
+	t.Run("WHEN printing a machine with choices THEN choices are diamonds and enabled transitions are green", func(t *testing.T) {
+		machine, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		out := string(hsm.NewDotPrinter[*choiceCtx]().Print(machine))
+
+		assert.Contains(t, out, `"c2" [shape=diamond`)
+		assert.Contains(t, out, `"c2" -> "end" [label="[else]"];`)
+		assert.Contains(t, out, `"end" [shape=doublecircle`)
+		assert.Contains(t, out, `"c0" -> "c1" [label="", color=green, fontcolor=green];`)
+		assert.NotContains(t, out, `"c1" -> "c2" [label="choiceSignal", color=green`)
+	})
+
+	t.Run("WHEN printing twice THEN output is deterministic", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		first := hsm.NewDotPrinter[*turnstileContext]().Print(machine)
+		second := hsm.NewDotPrinter[*turnstileContext]().Print(machine)
+
+		assert.Equal(t, string(first), string(second))
+		assert.Contains(t, string(first), `label="locked\nkick / count()"`)
+	})
+}
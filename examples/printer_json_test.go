@@ -0,0 +1,98 @@
+package examples_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPrinter(t *testing.T) {
+	t.Run("WHEN printing a nested machine THEN nodes describe the hierarchy", func(t *testing.T) {
+		machine, err := prepareOrderMachine(&orderContext{})
+		require.NoError(t, err)
+
+		doc := parseJSONGraph(t, hsm.NewJSONPrinter[*orderContext]().Print(machine))
+		nodes := make(map[string]jsonGraphNode)
+
+		for _, node := range doc.Nodes {
+			nodes[node.ID] = node
+		}
+
+		assert.Equal(t, "order", doc.Name)
+		assert.Equal(t, "s11", doc.Current)
+		assert.Equal(t, "s", nodes["s1"].Parent)
+		assert.Equal(t, "s2 entry", nodes["s2"].Entry)
+		assert.Equal(t, "entry", nodes["s2 entry"].Kind)
+		assert.Equal(t, "c()", nodes["s2"].OnEntry)
+		assert.Equal(t, "a()", nodes["s11"].OnExit)
+		assert.Equal(t, "error", nodes["error"].Kind)
+
+		assert.Contains(t, doc.Edges, jsonGraphEdge{
+			Source: "s1",
+			Target: "s2",
+			Signal: "*tSignal",
+			Guard:  "g()",
+			Effect: "t()",
+			Kind:   "normal",
+		})
+	})
+
+	t.Run("WHEN printing THEN transitions enabled from the current state are flagged", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		doc := parseJSONGraph(t, hsm.NewJSONPrinter[*turnstileContext]().Print(machine))
+
+		var enabled []string
+		for _, edge := range doc.Edges {
+			if edge.Enabled {
+				enabled = append(enabled, edge.Source+" -> "+edge.Target+" : "+edge.Signal+" ("+edge.Kind+")")
+			}
+		}
+
+		assert.Equal(t, "locked", doc.Current)
+		assert.Equal(t, []string{
+			"locked -> unlocked : coin (normal)",
+			"locked -> locked : kick (internal)",
+		}, enabled)
+	})
+}
+
+// jsonGraph mirrors documents printed by JSONPrinter.
+type (
+	jsonGraph struct {
+		Name    string          `json:"name"`
+		Current string          `json:"current"`
+		Nodes   []jsonGraphNode `json:"nodes"`
+		Edges   []jsonGraphEdge `json:"edges"`
+	}
+	jsonGraphNode struct {
+		ID      string `json:"id"`
+		Kind    string `json:"kind"`
+		Parent  string `json:"parent"`
+		Entry   string `json:"entry"`
+		OnEntry string `json:"onEntry"`
+		OnExit  string `json:"onExit"`
+	}
+	jsonGraphEdge struct {
+		Source  string `json:"source"`
+		Target  string `json:"target"`
+		Signal  string `json:"signal"`
+		Guard   string `json:"guard"`
+		Effect  string `json:"effect"`
+		Kind    string `json:"kind"`
+		Enabled bool   `json:"enabled"`
+	}
+)
+
+func parseJSONGraph(t *testing.T, out []byte) jsonGraph {
+	t.Helper()
+
+	var doc jsonGraph
+	require.NoError(t, json.Unmarshal(out, &doc), string(out))
+
+	return doc
+}
@@ -0,0 +1,94 @@
+package examples_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMermaidPrinter(t *testing.T) {
+	t.Run("WHEN printing a machine with choices THEN start, choice and final states are rendered", func(t *testing.T) {
+		machine, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		expected := strings.Join([]string{
+			"---",
+			"title: HSM choice",
+			"---",
+			"stateDiagram-v2",
+			"  state c1",
+			"  state c2 <<choice>>",
+			"  state c3",
+			"  state c4",
+			"  state c5",
+			"  state error",
+			"  [*] --> c1",
+			"  c1 --> c2 : choiceSignal",
+			"  c2 --> c3 : [g3]",
+			"  c2 --> c4 : [g4]",
+			"  c2 --> c5 : [g5]",
+			"  c2 --> [*] : [else]",
+			"  classDef error fill:#f99,stroke:#c00",
+			"  classDef current stroke:#00f,stroke-width:3px",
+			"  class error error",
+			"",
+		}, "\n")
+
+		assert.Equal(t, expected, string(hsm.NewMermaidPrinter[*choiceCtx]().Print(machine)))
+	})
+
+	t.Run("WHEN printing a nested machine THEN composite states, entry states and actions are rendered", func(t *testing.T) {
+		machine, err := prepareOrderMachine(&orderContext{})
+		require.NoError(t, err)
+
+		out := string(hsm.NewMermaidPrinter[*orderContext]().Print(machine))
+
+		assert.Contains(t, out, "  state s {\n    state s1 {\n      state s11\n")
+		assert.Contains(t, out, "    state s2 {\n      state s21\n      s21 : entry / e()\n      [*] --> s21 : / d()\n    }\n")
+		assert.Contains(t, out, "    s1 --> s2 : tSignal [g()] / t()\n")
+		assert.Contains(t, out, "  s : exit / f()\n")
+		assert.Contains(t, out, "  class s11 current\n")
+	})
+
+	t.Run("WHEN printing internal transitions THEN they are rendered as notes", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		out := string(hsm.NewMermaidPrinter[*turnstileContext]().Print(machine))
+
+		assert.Contains(t, out, "    note right of locked\n      kick / count()\n    end note\n")
+		assert.Contains(t, out, "  booth --> alarm : service:*\n")
+		assert.Equal(t, out, string(hsm.NewMermaidPrinter[*turnstileContext]().Print(machine)))
+	})
+
+	t.Run("WHEN state IDs are not valid Mermaid IDs THEN they are aliased", func(t *testing.T) {
+		waiting := hsm.NewState[*sharedContext]().
+			WithID("waiting room").
+			AddTransitions(
+				hsm.NewTransition[*sharedContext]().
+					When(&sharedSignal{}).
+					GoTo("in-call").
+					Build(),
+			).
+			Build()
+
+		machine, err := hsm.NewBuilder[*sharedContext]().
+			WithName("call").
+			StartingAt(waiting).
+			WithErrorState(hsm.NewErrorState[*sharedContext]().WithID("error").Build()).
+			AddState(waiting).
+			AddState(hsm.NewState[*sharedContext]().WithID("in-call").Build()).
+			Build()
+		require.NoError(t, err)
+
+		out := string(hsm.NewMermaidPrinter[*sharedContext]().Print(machine))
+
+		assert.Contains(t, out, "  state \"in-call\" as in_call\n")
+		assert.Contains(t, out, "  state \"waiting room\" as waiting_room\n")
+		assert.Contains(t, out, "  waiting_room --> in_call : sharedSignal\n")
+		assert.Contains(t, out, "  class waiting_room current\n")
+	})
+}
@@ -0,0 +1,181 @@
+package examples_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCXMLPrinter(t *testing.T) {
+	t.Run("WHEN printing a nested machine THEN states are nested and actions are scripts", func(t *testing.T) {
+		machine, err := prepareOrderMachine(&orderContext{})
+		require.NoError(t, err)
+
+		root := parseSCXML(t, hsm.NewSCXMLPrinter[*orderContext]().Print(machine))
+		s1 := root.find("s1")
+		s2 := root.find("s2")
+
+		assert.Equal(t, "order", root.attr("name"))
+		assert.Equal(t, "s11", root.attr("initial"))
+		assert.Equal(t, "s", root.find("s1").parent.attr("id"))
+		assert.Equal(t, "b()", s1.child("onexit").child("script").Text)
+
+		transition := s1.child("transition")
+		assert.Equal(t, "tSignal", transition.attr("event"))
+		assert.Equal(t, "g()", transition.attr("cond"))
+		assert.Equal(t, "s2", transition.attr("target"))
+		assert.Equal(t, "t()", transition.child("script").Text)
+
+		initial := s2.child("initial").child("transition")
+		assert.Equal(t, "s21", initial.attr("target"))
+		assert.Equal(t, "d()", initial.child("script").Text)
+	})
+
+	t.Run("WHEN printing choices THEN they become eventless guarded transitions", func(t *testing.T) {
+		machine, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		root := parseSCXML(t, hsm.NewSCXMLPrinter[*choiceCtx]().Print(machine))
+		choice := root.find("c2")
+
+		assert.Equal(t, "c1", root.attr("initial"))
+		assert.Nil(t, root.find("c0"))
+		assert.Equal(t, "final", root.find("end").XMLName.Local)
+
+		var branches []string
+		for _, c := range choice.Children {
+			assert.Empty(t, c.attr("event"))
+			branches = append(branches, fmt.Sprintf("%s->%s", c.attr("cond"), c.attr("target")))
+		}
+
+		assert.Equal(t, []string{"g3->c3", "g4->c4", "g5->c5", "->end"}, branches)
+	})
+
+	t.Run("WHEN printing internal transitions THEN they are targetless", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		root := parseSCXML(t, hsm.NewSCXMLPrinter[*turnstileContext]().Print(machine))
+
+		kick := root.find("locked").Children[1]
+		assert.Equal(t, "kick", kick.attr("event"))
+		assert.Empty(t, kick.attr("target"))
+		assert.Equal(t, "count()", kick.child("script").Text)
+	})
+}
+
+// scxmlNode is a generic SCXML element.
+type scxmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Children []*scxmlNode `xml:",any"`
+	Text     string       `xml:",chardata"`
+	parent   *scxmlNode
+}
+
+func (n *scxmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (n *scxmlNode) child(name string) *scxmlNode {
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (n *scxmlNode) find(id string) *scxmlNode {
+	for _, c := range n.Children {
+		if c.attr("id") == id {
+			return c
+		}
+
+		if found := c.find(id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// parseSCXML parses the given document and validates it against the SCXML structure.
+func parseSCXML(t *testing.T, out []byte) *scxmlNode {
+	root := &scxmlNode{}
+	require.NoError(t, xml.Unmarshal(out, root))
+
+	require.Equal(t, "scxml", root.XMLName.Local)
+	require.Equal(t, hsm.SCXMLNamespace, root.XMLName.Space)
+	require.Equal(t, "1.0", root.attr("version"))
+
+	var (
+		ids     = make(map[string]bool)
+		targets []string
+		idRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+		allowed = map[string][]string{
+			"scxml":      {"state", "final"},
+			"state":      {"state", "final", "initial", "onentry", "onexit", "transition"},
+			"final":      {"onentry", "onexit"},
+			"initial":    {"transition"},
+			"onentry":    {"script"},
+			"onexit":     {"script"},
+			"transition": {"script"},
+			"script":     {},
+		}
+	)
+
+	var walk func(n *scxmlNode)
+	walk = func(n *scxmlNode) {
+		for _, c := range n.Children {
+			c.parent = n
+			require.Contains(t, allowed[n.XMLName.Local], c.XMLName.Local, "<%s> within <%s>", c.XMLName.Local, n.XMLName.Local)
+
+			switch c.XMLName.Local {
+			case "state", "final":
+				id := c.attr("id")
+				require.Regexp(t, idRegex, id)
+				require.False(t, ids[id], "duplicated id `%s`", id)
+				ids[id] = true
+
+				if c.attr("initial") != "" {
+					require.Nil(t, c.child("initial"), "both initial attribute and element at `%s`", id)
+					targets = append(targets, c.attr("initial"))
+				}
+			case "initial":
+				require.Len(t, c.Children, 1)
+				require.NotEmpty(t, c.Children[0].attr("target"))
+			case "transition":
+				if target := c.attr("target"); target != "" {
+					targets = append(targets, target)
+				}
+			}
+
+			walk(c)
+		}
+	}
+
+	walk(root)
+
+	if initial := root.attr("initial"); initial != "" {
+		targets = append(targets, initial)
+	}
+
+	for _, target := range targets {
+		require.True(t, ids[target], "unknown target `%s`", target)
+	}
+
+	return root
+}
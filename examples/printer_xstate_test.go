@@ -0,0 +1,126 @@
+package examples_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXStatePrinter(t *testing.T) {
+	t.Run("WHEN printing a nested machine THEN initial states lead to the starting state", func(t *testing.T) {
+		machine, err := prepareOrderMachine(&orderContext{})
+		require.NoError(t, err)
+
+		root := parseXState(t, hsm.NewXStatePrinter[*orderContext]().Print(machine))
+		s := root.States["s"]
+
+		assert.Equal(t, "order", root.ID)
+		assert.Equal(t, "s", root.Initial)
+		assert.Equal(t, "s1", s.Initial)
+		assert.Equal(t, "s11", s.States["s1"].Initial)
+		assert.Equal(t, "s21", s.States["s2"].Initial)
+		assert.Equal(t, []string{"c()"}, s.States["s2"].Entry)
+		assert.Equal(t, []xstateTransition{{Target: "#s2", Cond: "g()", Actions: []string{"t()"}}}, s.States["s1"].On["tSignal"])
+		assert.NotContains(t, s.States, "s2 entry")
+	})
+
+	t.Run("WHEN printing choices THEN they hold always transitions", func(t *testing.T) {
+		machine, err := prepareChoiceMachine(&choiceCtx{})
+		require.NoError(t, err)
+
+		root := parseXState(t, hsm.NewXStatePrinter[*choiceCtx]().Print(machine))
+
+		assert.Equal(t, "c1", root.Initial)
+		assert.NotContains(t, root.States, "c0")
+		assert.Equal(t, "final", root.States["end"].Type)
+		assert.Equal(t, []xstateTransition{
+			{Target: "#c3", Cond: "g3"},
+			{Target: "#c4", Cond: "g4"},
+			{Target: "#c5", Cond: "g5"},
+			{Target: "#end"},
+		}, root.States["c2"].Always)
+	})
+
+	t.Run("WHEN printing internal transitions THEN they are targetless", func(t *testing.T) {
+		machine, err := prepareTurnstileMachine()
+		require.NoError(t, err)
+
+		root := parseXState(t, hsm.NewXStatePrinter[*turnstileContext]().Print(machine))
+
+		assert.Equal(t, []xstateTransition{{Actions: []string{"count()"}, Internal: true}}, root.States["booth"].States["locked"].On["kick"])
+	})
+
+	t.Run("WHEN unguarded transitions are declared first THEN guarded ones are still tried first", func(t *testing.T) {
+		machine, err := preparePriorityMachine()
+		require.NoError(t, err)
+
+		root := parseXState(t, hsm.NewXStatePrinter[*priorityContext]().Print(machine))
+
+		assert.Equal(t, []xstateTransition{
+			{Target: "#fast", Cond: "hurry"},
+			{Target: "#slow"},
+		}, root.States["idle"].On["go"])
+
+		require.NoError(t, machine.Signal(hsm.Named("go")))
+		assert.Equal(t, "fast", machine.Current().ID())
+	})
+}
+
+func preparePriorityMachine() (*hsm.HSM[*priorityContext], error) {
+	idle := hsm.NewState[*priorityContext]().
+		WithID("idle").
+		AddTransitions(
+			hsm.NewTransition[*priorityContext]().
+				When(hsm.Named("go")).
+				GoTo("slow").
+				Build(),
+			hsm.NewTransition[*priorityContext]().
+				When(hsm.Named("go")).
+				GuardedBy(hsm.NewGuard[*priorityContext]().WithLabel("hurry").WithMethod(func(*priorityContext) bool { return true }).Build()).
+				GoTo("fast").
+				Build(),
+		).
+		Build()
+
+	return hsm.NewBuilder[*priorityContext]().
+		WithName("priority").
+		WithContext(&priorityContext{}).
+		StartingAt(idle).
+		WithErrorState(hsm.NewErrorState[*priorityContext]().WithID("error").Build()).
+		AddStates(idle, hsm.NewState[*priorityContext]().WithID("slow").Build(), hsm.NewState[*priorityContext]().WithID("fast").Build()).
+		Build()
+}
+
+type priorityContext struct{}
+
+// xstateNode mirrors XState state node configurations.
+type (
+	xstateNode struct {
+		ID      string                        `json:"id"`
+		Type    string                        `json:"type"`
+		Initial string                        `json:"initial"`
+		Entry   []string                      `json:"entry"`
+		Exit    []string                      `json:"exit"`
+		On      map[string][]xstateTransition `json:"on"`
+		Always  []xstateTransition            `json:"always"`
+		States  map[string]*xstateNode        `json:"states"`
+	}
+	xstateTransition struct {
+		Target   string   `json:"target"`
+		Cond     string   `json:"cond"`
+		Actions  []string `json:"actions"`
+		Internal bool     `json:"internal"`
+	}
+)
+
+func parseXState(t *testing.T, out []byte) *xstateNode {
+	t.Helper()
+
+	var root xstateNode
+	require.NoError(t, json.Unmarshal(out, &root), string(out))
+
+	return &root
+}
@@ -0,0 +1,129 @@
+package examples_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NOTE: this tests uses order-machine example definition
+func TestTracer(t *testing.T) {
+	recorder := hsm.NewRecorder()
+	machine, err := hsm.NewBuilder[*orderContext]().
+		// meta
+		WithName("order").
+		WithContext(&orderContext{}).
+		WithTracer(recorder).
+		StartingAt(s11).
+		WithErrorState(hsm.NewErrorState[*orderContext]().WithID("error").Build()).
+
+		// states
+		AddState(w).
+		AddState(s).
+		AddState(s1).
+		AddState(s11).
+		AddState(s2).
+		AddState(s21).
+
+		// build
+		Build()
+
+	require.NoError(t, err)
+	require.NoError(t, machine.Signal(&tSignal{}))
+
+	spans := recorder.Spans()
+	names := make([]string, 0, len(spans))
+
+	for _, span := range spans {
+		names = append(names, fmt.Sprintf("%s %v", span.Name, span.Attributes[hsm.AttributeLabel]))
+	}
+
+	assert.Equal(t, []string{
+		"hsm.guard g()",
+		"hsm.exit a()",
+		"hsm.exit b()",
+		"hsm.effect t()",
+		"hsm.entry c()",
+		"hsm.effect d()",
+		"hsm.entry e()",
+		"hsm.signal <nil>",
+	}, names)
+
+	root := spans[len(spans)-1]
+	assert.Equal(t, 0, root.ParentID)
+	assert.Equal(t, "order", root.Attributes[hsm.AttributeMachine])
+	assert.Equal(t, "s11", root.Attributes[hsm.AttributeSource])
+	assert.Equal(t, "s21", root.Attributes[hsm.AttributeTarget])
+	assert.Equal(t, "*tSignal", root.Attributes[hsm.AttributeSignal])
+	assert.NoError(t, root.Err)
+
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, root.ID, span.ParentID)
+		assert.GreaterOrEqual(t, span.Duration().Nanoseconds(), int64(0))
+	}
+
+	assert.Equal(t, true, spans[0].Attributes[hsm.AttributeGuardResult])
+	assert.Equal(t, "s11", spans[1].Attributes[hsm.AttributeVertex])
+}
+
+func TestRestoreTracer(t *testing.T) {
+	recorder := hsm.NewRecorder()
+	context := &orderContext{}
+
+	waiting := hsm.NewState[*orderContext]().
+		WithID("waiting").
+		AddTransitions(
+			hsm.NewTransition[*orderContext]().
+				ApplyEffect(
+					hsm.NewEffect[*orderContext]().
+						WithLabel("notify()").
+						WithMethod(func(ctx *orderContext, _ hsm.Signal) error {
+							ctx.calls = append(ctx.calls, "notify()")
+
+							return nil
+						}).
+						Build(),
+				).
+				GoTo("done").
+				Build(),
+		).
+		Build()
+
+	done := hsm.NewFinalState[*orderContext]().WithID("done").Build()
+
+	machine, err := hsm.NewBuilder[*orderContext]().
+		// meta
+		WithName("restore").
+		WithContext(context).
+		WithTracer(recorder).
+		StartingAt(done).
+		WithErrorState(hsm.NewErrorState[*orderContext]().WithID("error").Build()).
+
+		// states
+		AddState(waiting).
+		AddState(done).
+
+		// build
+		Restore(hsm.Snapshot{StateID: "waiting"})
+
+	require.NoError(t, err)
+	require.True(t, machine.At(done))
+	require.Equal(t, []string{"notify()"}, context.calls)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+
+	root := spans[1]
+	assert.Equal(t, hsm.SpanRestore, root.Name)
+	assert.Equal(t, 0, root.ParentID)
+	assert.Equal(t, "restore", root.Attributes[hsm.AttributeMachine])
+	assert.Equal(t, "waiting", root.Attributes[hsm.AttributeSource])
+	assert.Equal(t, "done", root.Attributes[hsm.AttributeTarget])
+	assert.NoError(t, root.Err)
+
+	assert.Equal(t, hsm.SpanEffect, spans[0].Name)
+	assert.Equal(t, root.ID, spans[0].ParentID)
+}
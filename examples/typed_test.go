@@ -0,0 +1,189 @@
+package examples_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/botchris/go-hsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedTransitions(t *testing.T) {
+	t.Run("WHEN sending typed signals THEN effects receive their payload", func(t *testing.T) {
+		context := &accountContext{}
+		machine, err := prepareAccountMachine(context)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&depositSignal{Amount: 100}))
+		require.NoError(t, machine.Signal(&depositSignal{Amount: 50}))
+		assert.Equal(t, 150, context.balance)
+		assert.True(t, machine.At(activeAccountState))
+	})
+
+	t.Run("WHEN typed guard does not hold THEN signal is rejected", func(t *testing.T) {
+		context := &accountContext{}
+		machine, err := prepareAccountMachine(context)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&depositSignal{Amount: 100}))
+		assert.Error(t, machine.Signal(&withdrawSignal{Amount: 500}))
+		assert.Equal(t, 100, context.balance)
+
+		require.NoError(t, machine.Signal(&withdrawSignal{Amount: 100}))
+		assert.Equal(t, 0, context.balance)
+		assert.True(t, machine.At(emptyAccountState))
+	})
+
+	t.Run("WHEN signal type is an interface THEN any implementation triggers the transition", func(t *testing.T) {
+		context := &accountContext{}
+		machine, err := prepareAccountMachine(context)
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&depositSignal{Amount: 10}))
+		require.NoError(t, machine.Signal(&fraudSignal{reason: "stolen card"}))
+		assert.True(t, machine.At(frozenAccountState))
+		assert.Equal(t, "stolen card", context.frozenBecause)
+	})
+
+	t.Run("WHEN checking typed transitions THEN signal-aware guards are assumed to hold", func(t *testing.T) {
+		machine, err := prepareAccountMachine(&accountContext{})
+		require.NoError(t, err)
+
+		require.NoError(t, machine.Signal(&depositSignal{Amount: 10}))
+		assert.True(t, machine.Can(&withdrawSignal{}))
+		assert.Len(t, machine.AvailableSignals(), 2)
+	})
+
+	t.Run("WHEN typed effect fails THEN its compensation receives the payload", func(t *testing.T) {
+		context := &accountContext{failAbove: 1000}
+		machine, err := prepareAccountMachine(context)
+		require.NoError(t, err)
+
+		assert.Error(t, machine.Signal(&depositSignal{Amount: 2000}))
+		assert.Equal(t, 0, context.balance)
+		assert.True(t, machine.At(emptyAccountState))
+	})
+}
+
+func prepareAccountMachine(context *accountContext) (*hsm.HSM[*accountContext], error) {
+	return hsm.NewBuilder[*accountContext]().
+		// meta
+		WithName("account").
+		WithContext(context).
+		WithTransactionalTransitions().
+		StartingAt(emptyAccountState).
+		WithErrorState(hsm.NewErrorState[*accountContext]().WithID("error").Build()).
+
+		// states
+		AddState(emptyAccountState).
+		AddState(activeAccountState).
+		AddState(frozenAccountState).
+
+		// build
+		Build()
+}
+
+// SIGNALS & CONTEXT.
+type (
+	depositSignal struct {
+		Amount int
+	}
+
+	withdrawSignal struct {
+		Amount int
+	}
+
+	suspicionSignal interface {
+		Reason() string
+	}
+
+	fraudSignal struct {
+		reason string
+	}
+
+	accountContext struct {
+		balance       int
+		failAbove     int
+		frozenBecause string
+	}
+)
+
+func (f *fraudSignal) Reason() string {
+	return f.reason
+}
+
+// STATE IDS.
+var (
+	emptyAccountID  = "empty"
+	activeAccountID = "active"
+	frozenAccountID = "frozen"
+)
+
+// MACHINE PARTS.
+var emptyAccountState = hsm.NewState[*accountContext]().
+	WithID(emptyAccountID).
+	AddTransitions(
+		hsm.On[*accountContext, *depositSignal]().
+			ApplyEffect("deposit()", func(ctx *accountContext, s *depositSignal) error {
+				ctx.balance += s.Amount
+
+				return nil
+			}).
+			WithCompensation(func(ctx *accountContext, s *depositSignal) error {
+				ctx.balance -= s.Amount
+
+				return nil
+			}).
+			GoTo(activeAccountID).
+			Build(),
+	).
+	Build()
+
+var activeAccountState = hsm.NewState[*accountContext]().
+	WithID(activeAccountID).
+	OnEntry(
+		hsm.NewAction[*accountContext]().
+			WithLabel("check()").
+			WithMethod(func(ctx *accountContext, signal hsm.Signal) error {
+				if ctx.failAbove > 0 && ctx.balance > ctx.failAbove {
+					return errors.New("deposit too large")
+				}
+
+				return nil
+			}).
+			Build(),
+	).
+	AddTransitions(
+		hsm.OnInternal[*accountContext, *depositSignal]().
+			ApplyEffect("deposit()", func(ctx *accountContext, s *depositSignal) error {
+				ctx.balance += s.Amount
+
+				return nil
+			}).
+			Build(),
+		hsm.On[*accountContext, *withdrawSignal]().
+			GuardedBy("amount == balance", func(ctx *accountContext, s *withdrawSignal) bool {
+				return s.Amount == ctx.balance
+			}).
+			ApplyEffect("withdraw()", func(ctx *accountContext, s *withdrawSignal) error {
+				ctx.balance -= s.Amount
+
+				return nil
+			}).
+			GoTo(emptyAccountID).
+			Build(),
+		hsm.On[*accountContext, suspicionSignal]().
+			ApplyEffect("freeze()", func(ctx *accountContext, s suspicionSignal) error {
+				ctx.frozenBecause = s.Reason()
+
+				return nil
+			}).
+			GoTo(frozenAccountID).
+			Build(),
+	).
+	Build()
+
+var frozenAccountState = hsm.NewState[*accountContext]().
+	WithID(frozenAccountID).
+	Build()
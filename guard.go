@@ -1,16 +1,94 @@
 package hsm
 
+import "fmt"
+
 // GuardFunc public definition of guard functions.
 type GuardFunc[C any] func(ctx C) bool
 
+// SignalGuardFunc public definition of guard functions inspecting the triggering signal.
+type SignalGuardFunc[C any] func(ctx C, signal Signal) bool
+
+// FallibleGuardFunc public definition of guard functions that may fail, e.g. because they
+// rely on I/O. Failed guards abort the dispatch, see `GuardError`.
+type FallibleGuardFunc[C any] func(ctx C, signal Signal) (bool, error)
+
+// GuardErrorPolicy defines how HSMs react to failed guards.
+type GuardErrorPolicy int
+
+const (
+	// GuardErrorAbort aborts the dispatch, the HSM remains at its current state.
+	GuardErrorAbort GuardErrorPolicy = iota
+
+	// GuardErrorEnterErrorState aborts the dispatch and moves the HSM to its error state.
+	GuardErrorEnterErrorState
+)
+
+// GuardError is returned by `HSM.Signal()` when a guard fails, no exit action has been
+// run when it is returned.
+type GuardError struct {
+	// Name of the machine where the guard failed
+	Machine string
+
+	// ID of the state the dispatch started from
+	State string
+
+	// Kind of the signal being dispatched
+	Signal string
+
+	// Label of the failed guard
+	Guard string
+
+	// Err is the error returned by the guard
+	Err error
+}
+
+// Error implements error.
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("guard `%s` failed at state `%s` for signal `%s`, hsm `%s`: %s",
+		e.Guard, e.State, e.Signal, e.Machine, e.Err)
+}
+
+// Unwrap returns the error returned by the guard.
+func (e *GuardError) Unwrap() error {
+	return e.Err
+}
+
 // Guard definition of transition guard, checks whether a transition can be performed or not based on given context;
 // they MUST be side effect free, at least none that would alter evaluation of other guards having the same trigger.
 type Guard[C any] struct {
 	label  string
 	method GuardFunc[C]
+
+	// signal-aware method, used instead of method when defined
+	signalMethod SignalGuardFunc[C]
+
+	// fallible method, used instead of any other method when defined
+	fallibleMethod FallibleGuardFunc[C]
 }
 
 // NewGuard starts building a new guard condition.
 func NewGuard[C any]() GuardBuilder[C] {
 	return &guardBuilder[C]{}
 }
+
+// check evaluates this guard for the given triggering signal.
+func (g *Guard[C]) check(ctx C, signal Signal) (bool, error) {
+	switch {
+	case g.fallibleMethod != nil:
+		return g.fallibleMethod(ctx, signal)
+	case g.signalMethod != nil:
+		return g.signalMethod(ctx, signal), nil
+	}
+
+	return g.method(ctx), nil
+}
+
+// available evaluates this guard when no triggering signal is known, signal-aware and
+// fallible guards are assumed to hold as they cannot be evaluated.
+func (g *Guard[C]) available(ctx C) bool {
+	if g.signalMethod != nil || g.fallibleMethod != nil {
+		return true
+	}
+
+	return g.method(ctx)
+}
@@ -4,13 +4,17 @@ package hsm
 type GuardBuilder[C any] interface {
 	WithLabel(label string) GuardBuilder[C]
 	WithMethod(method GuardFunc[C]) GuardBuilder[C]
+	WithSignalMethod(method SignalGuardFunc[C]) GuardBuilder[C]
+	WithFallibleMethod(method FallibleGuardFunc[C]) GuardBuilder[C]
 	Build() *Guard[C]
 }
 
 // guardBuilder private guard builder.
 type guardBuilder[C any] struct {
-	label  string
-	method GuardFunc[C]
+	label          string
+	method         GuardFunc[C]
+	signalMethod   SignalGuardFunc[C]
+	fallibleMethod FallibleGuardFunc[C]
 }
 
 // WithLabel defines guard's label.
@@ -27,10 +31,32 @@ func (b *guardBuilder[C]) WithMethod(method GuardFunc[C]) GuardBuilder[C] {
 	return b
 }
 
+// WithSignalMethod defines guard's method as a function of the context and the triggering
+// signal, it replaces any method given to `WithMethod`. Signal-aware guards cannot be
+// evaluated without a concrete signal, so `HSM.Can()` and `HSM.AvailableSignals()` assume
+// they hold; use `HSM.CanWith()` for evaluating them against a given signal.
+func (b *guardBuilder[C]) WithSignalMethod(method SignalGuardFunc[C]) GuardBuilder[C] {
+	b.signalMethod = method
+
+	return b
+}
+
+// WithFallibleMethod defines guard's method as a function that may fail, it replaces any
+// other method. Like signal-aware guards, fallible guards are assumed to hold by
+// `HSM.Can()` and `HSM.AvailableSignals()`, and `HSM.CanWith()` reports failed guards as
+// not holding.
+func (b *guardBuilder[C]) WithFallibleMethod(method FallibleGuardFunc[C]) GuardBuilder[C] {
+	b.fallibleMethod = method
+
+	return b
+}
+
 // Build finalizes the building process of this guard.
 func (b *guardBuilder[C]) Build() *Guard[C] {
 	return &Guard[C]{
-		label:  b.label,
-		method: b.method,
+		label:          b.label,
+		method:         b.method,
+		signalMethod:   b.signalMethod,
+		fallibleMethod: b.fallibleMethod,
 	}
 }
@@ -0,0 +1,216 @@
+package hsm
+
+import (
+	"sort"
+	"time"
+)
+
+// HistoryKind describes the kind of transition a history entry refers to.
+type HistoryKind string
+
+// Kinds of history entries.
+const (
+	// HistoryKindNormal normal transitions triggered by a signal
+	HistoryKindNormal HistoryKind = "normal"
+
+	// HistoryKindInternal internal transitions, those not producing a change of state
+	HistoryKindInternal HistoryKind = "internal"
+
+	// HistoryKindCompletion signal-less transitions, including choice branches
+	HistoryKindCompletion HistoryKind = "completion"
+)
+
+// HistoryEntry records a single transition taken by a HSM.
+type HistoryEntry struct {
+	// When the transition started
+	Time time.Time
+
+	// Kind of the signal that triggered the transition, `nil` for completion transitions
+	Signal string
+
+	// ID of the state the transition started from
+	Source string
+
+	// ID of the state the transition went to
+	Target string
+
+	// Kind of the transition
+	Kind HistoryKind
+
+	// Label of the guard that allowed the transition, empty if unguarded
+	Guard string
+
+	// How long the transition took to complete
+	Duration time.Duration
+}
+
+// TimeInState computes, based on this snapshot history, how long the HSM stayed at each
+// state. Time spent at the current state is computed up to the given instant, and time
+// spent at the state preceding the first recorded entry is unknown thus not included.
+func (s Snapshot) TimeInState(now time.Time) map[string]time.Duration {
+	var (
+		result  = make(map[string]time.Duration)
+		entries = make([]HistoryEntry, 0, len(s.History))
+	)
+
+	for _, e := range s.History {
+		if e.Kind != HistoryKindInternal {
+			entries = append(entries, e)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	for i, e := range entries {
+		until := now
+		if i+1 < len(entries) {
+			until = entries[i+1].Time
+		} else if e.Target != s.StateID {
+			continue
+		}
+
+		if spent := until.Sub(e.Time.Add(e.Duration)); spent > 0 {
+			result[e.Target] += spent
+		}
+	}
+
+	return result
+}
+
+const (
+	historyPolicyUnlimited = iota
+	historyPolicyRing
+	historyPolicyWindow
+	historyPolicyDisabled
+)
+
+// historyPolicyKind private definition of history policy types.
+type historyPolicyKind int
+
+// HistoryPolicy defines how much history a HSM retains, it applies to signals history,
+// states history and history entries alike. The zero value retains everything.
+type HistoryPolicy struct {
+	kind   historyPolicyKind
+	size   int
+	window time.Duration
+}
+
+// UnlimitedHistory retains the entire history, default policy. History grows with every
+// transition, so signals allocate; use `RingHistory` for long-running machines.
+func UnlimitedHistory() HistoryPolicy {
+	return HistoryPolicy{kind: historyPolicyUnlimited}
+}
+
+// RingHistory retains only the latest `size` items of each history.
+func RingHistory(size int) HistoryPolicy {
+	if size <= 0 {
+		return DisabledHistory()
+	}
+
+	return HistoryPolicy{kind: historyPolicyRing, size: size}
+}
+
+// WindowedHistory retains only the history entries of transitions started within the
+// given time window, along with their signals, and the states entered within that window
+// plus the state the machine was at when the window started.
+func WindowedHistory(window time.Duration) HistoryPolicy {
+	return HistoryPolicy{kind: historyPolicyWindow, window: window}
+}
+
+// DisabledHistory retains no history at all.
+func DisabledHistory() HistoryPolicy {
+	return HistoryPolicy{kind: historyPolicyDisabled}
+}
+
+// enabled whether anything is retained by this policy.
+func (p HistoryPolicy) enabled() bool {
+	return p.kind != historyPolicyDisabled
+}
+
+// retain trims the given histories so their memory usage stays bounded, amortizing the
+// cost of trimming by letting them grow up to twice the retained size. Signals are
+// recorded along with entries, whereas states are recorded along with the instant they
+// were entered at, as internal transitions do not change state and entering the error
+// state records no entry.
+func (p HistoryPolicy) retain(signals, states []string, entered []time.Time, entries []HistoryEntry, now time.Time) ([]string, []string, []time.Time, []HistoryEntry) {
+	switch p.kind {
+	case historyPolicyRing:
+		return compact(signals, p.size), compact(states, p.size), compact(entered, p.size), compact(entries, p.size)
+	case historyPolicyWindow:
+		entries = compact(entries, len(entries)-p.expired(entries, now))
+		kept := len(states) - p.departed(entered, now)
+
+		return compact(signals, len(entries)), compact(states, kept), compact(entered, kept), entries
+	}
+
+	return signals, states, entered, entries
+}
+
+// view returns the portion of the given histories which is visible under this policy.
+func (p HistoryPolicy) view(signals, states []string, entered []time.Time, entries []HistoryEntry, now time.Time) ([]string, []string, []HistoryEntry) {
+	switch p.kind {
+	case historyPolicyRing:
+		return tail(signals, p.size), tail(states, p.size), tail(entries, p.size)
+	case historyPolicyWindow:
+		entries = entries[p.expired(entries, now):]
+
+		return tail(signals, len(entries)), states[p.departed(entered, now):], entries
+	case historyPolicyDisabled:
+		return []string{}, []string{}, []HistoryEntry{}
+	}
+
+	return signals, states, entries
+}
+
+// expired returns how many of the given entries fall outside this policy time window.
+func (p HistoryPolicy) expired(entries []HistoryEntry, now time.Time) int {
+	cutoff := now.Add(-p.window)
+
+	return sort.Search(len(entries), func(i int) bool {
+		return !entries[i].Time.Before(cutoff)
+	})
+}
+
+// departed returns how many of the states entered at the given instants were left before
+// this policy time window, the state current when the window started being retained.
+func (p HistoryPolicy) departed(entered []time.Time, now time.Time) int {
+	cutoff := now.Add(-p.window)
+
+	n := sort.Search(len(entered), func(i int) bool {
+		return !entered[i].Before(cutoff)
+	})
+
+	if n > 0 {
+		n--
+	}
+
+	return n
+}
+
+// compact keeps only the latest `size` items of the given list once it has grown twice
+// as large, reusing its backing array.
+func compact[T any](list []T, size int) []T {
+	if len(list) < 2*size || len(list) <= size {
+		return list
+	}
+
+	n := copy(list, list[len(list)-size:])
+
+	var zero T
+	for i := n; i < len(list); i++ {
+		list[i] = zero
+	}
+
+	return list[:n]
+}
+
+// tail returns the latest `size` items of the given list.
+func tail[T any](list []T, size int) []T {
+	if len(list) <= size {
+		return list
+	}
+
+	return list[len(list)-size:]
+}
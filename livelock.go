@@ -0,0 +1,48 @@
+package hsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxMicrosteps is how many transitions a single `Signal` call may take by
+// default, see `Builder.WithMaxMicrosteps`.
+const DefaultMaxMicrosteps = 100
+
+// ErrLivelock is returned by `HSM.Signal()` when a signal took more transitions than
+// allowed, usually because states are linked by signal-less transitions whose guards
+// always hold. The HSM is at its error state when it is returned.
+//
+//nolint:errname // exported name is part of the public API, matched using errors.As
+type ErrLivelock struct {
+	// Name of the machine where the livelock was detected
+	Machine string
+
+	// How many transitions were allowed
+	Microsteps int
+
+	// IDs of the states looping into each other in the order they were visited, starting
+	// and ending at the same state, or the states every transition started from when no
+	// state was visited twice
+	Cycle []string
+}
+
+// Error implements error.
+func (e *ErrLivelock) Error() string {
+	return fmt.Sprintf("livelock detected after %d microsteps through `%s`, hsm `%s`",
+		e.Microsteps, strings.Join(e.Cycle, "` -> `"), e.Machine)
+}
+
+// cycleOf returns the last cycle of the given sequence of state IDs, starting and ending
+// at the state the sequence ends at, or the whole sequence if no state is repeated.
+func cycleOf(path []string) []string {
+	last := len(path) - 1
+
+	for i := last - 1; i >= 0; i-- {
+		if path[i] == path[last] {
+			return append([]string(nil), path[i:]...)
+		}
+	}
+
+	return append([]string(nil), path...)
+}
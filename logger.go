@@ -0,0 +1,102 @@
+package hsm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel defines the severity of log messages.
+type LogLevel int
+
+// Log levels, sorted by severity.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String returns a string representation of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Logger is a structured logger used by HSMs for reporting relevant events. Fields are
+// given as alternating key/value pairs, e.g. `"hsm", "door", "state", "open"`.
+type Logger interface {
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NopLogger returns a logger that discards every message, used by default.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// nopLogger discards every message.
+type nopLogger struct{}
+
+// Log implements Logger.
+func (nopLogger) Log(LogLevel, string, ...interface{}) {}
+
+// NewStdLogger returns a logger writing to the given standard library logger using
+// logfmt-like lines, messages below the given level are discarded.
+//
+// Usage:
+//
+//	logger := hsm.NewStdLogger(log.Default(), hsm.LogLevelInfo)
+//	machine, err := hsm.NewBuilder[*MyContext]().WithLogger(logger)...
+func NewStdLogger(logger *log.Logger, level LogLevel) Logger {
+	return &stdLogger{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// stdLogger adapts a standard library logger.
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// Log implements Logger.
+func (l *stdLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if level < l.level {
+		return
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf("level=%s msg=%q", level, msg))
+
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+
+		buf.WriteString(fmt.Sprintf(" %v=%s", keyvals[i], l.format(value)))
+	}
+
+	l.logger.Print(buf.String())
+}
+
+// format renders the given value, quoting it if necessary.
+func (l *stdLogger) format(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+
+	return s
+}
@@ -1,29 +1,40 @@
 package hsm
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 )
 
 // HSM represents a finite state machine.
 type HSM[C any] struct {
-	// human-readable name of this machine
-	name string
+	// definition this machine is an instance of
+	def *Definition[C]
 
 	// serves as machine's extended states
 	// see: https://en.wikipedia.org/wiki/UML_state_machine#Extended_states
 	context C
 
-	// list of states within this machine
-	states map[string]*Vertex[C]
-
 	// pointer to the current state
 	currentState *Vertex[C]
 
-	// pointer to a state that will be entered whenever an error occurs in the state
-	// machine.
-	errorState *Vertex[C]
+	// when the current state was entered
+	enteredAt time.Time
+
+	// parent span of the signal being currently processed, if any
+	span Span
+
+	// completion transition already found enabled when entering the current state, taken
+	// by the next completion step instead of evaluating guards again
+	completion *Transition[C]
+
+	// observers notified about this HSM lifecycle
+	observers observers
+
+	// observers notified about the signal being processed, taken once per signal
+	notified observers
 
 	// holds a history of (successfully) triggered signals in this HSM
 	signalsHistory []string
@@ -31,11 +42,24 @@ type HSM[C any] struct {
 	// holds a sequence history of states this HSM has been passing through
 	statesHistory []string
 
+	// when each state of statesHistory was entered
+	statesEnteredAt []time.Time
+
+	// holds a detailed history of the transitions taken by this HSM
+	history []HistoryEntry
+
+	// IDs of the states each transition of the signal being processed started from,
+	// reused across signals
+	microsteps []string
+
 	// guards access to HSM Signal() method
 	signalMutex sync.RWMutex
 
 	// guards to HSM current state
 	currentMutex sync.RWMutex
+
+	// guards access to HSM observers
+	observersMutex sync.RWMutex
 }
 
 // Snapshot provides a public snapshot.
@@ -51,6 +75,27 @@ type Snapshot struct {
 
 	// History of states this HSM been at
 	StatesHistory []string
+
+	// Detailed history of the transitions taken by this HSM
+	History []HistoryEntry
+}
+
+// Definition returns the definition this HSM is an instance of.
+func (h *HSM[C]) Definition() *Definition[C] {
+	return h.def
+}
+
+// States returns every vertex of this HSM, including pseudo-states, sorted by ID.
+func (h *HSM[C]) States() []*Vertex[C] {
+	return h.def.States()
+}
+
+// Context retrieves HSM`s context.
+func (h *HSM[C]) Context() C {
+	h.currentMutex.RLock()
+	defer h.currentMutex.RUnlock()
+
+	return h.context
 }
 
 // Current retrieves HSM`s current state.
@@ -111,17 +156,42 @@ func (h *HSM[C]) Failed() bool {
 	h.currentMutex.RLock()
 	defer h.currentMutex.RUnlock()
 
-	return h.currentState == h.errorState
+	return h.currentState == h.def.errorState
 }
 
 // Can check whether the given trigger CAN be signaled, that is, it will produce a
-// transition.
+// transition. The given signal is only used for matching, signal-aware guards are
+// assumed to hold; see `CanWith()`.
 func (h *HSM[C]) Can(signal Signal) bool {
 	h.currentMutex.RLock()
 	defer h.currentMutex.RUnlock()
 
-	for _, t := range h.AvailableSignals() {
-		if h.kind(t) == h.kind(signal) {
+	for _, t := range h.currentState.lookup(signal) {
+		if t.matches(signal) && (t.guard == nil || t.guard.available(h.context)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanWith check whether the given signal would produce a transition if it were sent now,
+// every guard is evaluated against the given signal including signal-aware ones. Unlike
+// `Signal()`, no guard evaluation is traced.
+func (h *HSM[C]) CanWith(signal Signal) bool {
+	h.currentMutex.RLock()
+	defer h.currentMutex.RUnlock()
+
+	for _, t := range h.currentState.lookup(signal) {
+		if !t.matches(signal) {
+			continue
+		}
+
+		if t.guard == nil {
+			return true
+		}
+
+		if ok, err := t.guard.check(h.context, signal); ok && err == nil {
 			return true
 		}
 	}
@@ -130,16 +200,56 @@ func (h *HSM[C]) Can(signal Signal) bool {
 }
 
 // Signal sends the given signal and fires corresponding transitions if available from
-// current state.
+// current state. Unguarded transitions allocate nothing when history is disabled, or
+// bounded by `RingHistory` once it is full. With the default `UnlimitedHistory`, every
+// transition appends to history, which allocates as it grows.
 func (h *HSM[C]) Signal(signal Signal) error {
 	h.signalMutex.Lock()
 	defer h.signalMutex.Unlock()
 
-	if err := h.tryProgress(); err != nil {
-		return err
+	h.watch()
+
+	if len(h.notified) > 0 {
+		h.notified.signalReceived(h.event(signal, h.currentState.id, ""))
 	}
 
-	return h.apply(signal)
+	h.startTrace(signal)
+	h.microsteps = h.microsteps[:0]
+
+	err := h.tryProgress()
+	if err == nil {
+		err = h.dispatch(signal, false)
+	}
+
+	h.endTrace(err)
+
+	return err
+}
+
+// AddObserver registers an observer that will be notified about this HSM lifecycle,
+// starting from the next signal.
+func (h *HSM[C]) AddObserver(observer Observer) {
+	h.observersMutex.Lock()
+	defer h.observersMutex.Unlock()
+
+	h.observers = append(h.observers[:len(h.observers):len(h.observers)], observer)
+}
+
+// RemoveObserver unregisters the given observer, observers are compared using the `==`
+// operator so they must be comparable (e.g. pointers).
+func (h *HSM[C]) RemoveObserver(observer Observer) {
+	h.observersMutex.Lock()
+	defer h.observersMutex.Unlock()
+
+	list := make(observers, 0, len(h.observers))
+
+	for _, o := range h.observers {
+		if o != observer {
+			list = append(list, o)
+		}
+	}
+
+	h.observers = list
 }
 
 // Snapshot returns a serializable snapshot of this HSM.
@@ -147,96 +257,121 @@ func (h *HSM[C]) Snapshot() Snapshot {
 	h.currentMutex.RLock()
 	defer h.currentMutex.RUnlock()
 
+	signals, states, entries := h.def.historyPolicy.view(h.signalsHistory, h.statesHistory, h.statesEnteredAt, h.history, time.Now())
+
 	return Snapshot{
 		StateID:        h.currentState.id,
 		Final:          h.currentState.Final(),
-		SignalsHistory: h.signalsHistory,
-		StatesHistory:  h.statesHistory,
+		SignalsHistory: append(make([]string, 0, len(signals)), signals...),
+		StatesHistory:  append(make([]string, 0, len(states)), states...),
+		History:        append(make([]HistoryEntry, 0, len(entries)), entries...),
 	}
 }
 
+// TimeInState returns how long this HSM stayed at each state, based on its history.
+func (h *HSM[C]) TimeInState() map[string]time.Duration {
+	return h.Snapshot().TimeInState(time.Now())
+}
+
 // AvailableSignals returns a set of events **susceptible** of producing a transition from the outside considering
-// HSM`s current state; signals that could be used.
+// HSM`s current state; signals that could be used. Transitions using interface or predicate matchers are not
+// considered, as no signal instance is known for them, and signal-aware guards are assumed to hold.
 func (h *HSM[C]) AvailableSignals() []Signal {
-	var (
-		signals = make(map[string]Signal)
-		results = make([]Signal, 0)
-	)
-
-	for _, t := range h.currentState.edges.list() {
-		if t.guard == nil || t.guard.method(h.context) {
-			signals[h.kind(t.signal)] = t.signal
-		}
-	}
+	results := make([]Signal, 0, len(h.currentState.candidates))
 
-	parent := h.currentState.parent
-	for parent != nil {
-		for _, t := range parent.edges.list() {
-			if t.guard == nil || t.guard.method(h.context) {
-				signals[h.kind(t.signal)] = t.signal
+next:
+	for _, t := range h.currentState.candidates {
+		for _, s := range results {
+			if t.matches(s) {
+				continue next
 			}
 		}
 
-		parent = parent.parent
+		if t.guard == nil || t.guard.available(h.context) {
+			results = append(results, t.signal)
+		}
 	}
 
-	for _, evt := range signals {
-		results = append(results, evt)
+	return results
+}
+
+// dispatch sends the given signal through the dispatch chain of this HSM.
+func (h *HSM[C]) dispatch(signal Signal, completion bool) error {
+	if h.def.dispatcher == nil {
+		return h.apply(signal)
 	}
 
-	return results
+	return h.def.dispatcher(h, Dispatch{Signal: signal, Completion: completion})
 }
 
 // apply Applies the given signal on this HSM.
 func (h *HSM[C]) apply(signal Signal) error {
-	// do while
-	var nextState = h.currentState
-
-	for ok := true; ok; ok = nextState != nil {
-		transition := h.getTransition(nextState, signal)
+	if err := h.microstep(signal); err != nil {
+		return err
+	}
 
-		// If there were no transitions for the given signal for the current
-		// state, check if there are any transitions for any of the parent
-		// states (if any):
-		if transition == nil {
-			nextState = nextState.parent
+	// Transitions of the current state are looked up first, then those of its parents
+	transition, err := h.completion, error(nil)
+	h.completion = nil
 
-			continue
+	if transition == nil || signal != nil {
+		if transition, err = h.getTransition(h.currentState, signal); err != nil {
+			return h.guardFailed(signal, err)
 		}
+	}
 
+	if transition != nil {
 		// A transition must have a next state defined. If the user has not
 		// defined the next state, go to error state:
 		if transition.nextStatePtr == nil {
-			h.goToErrorState(signal)
+			err := fmt.Errorf("transition has no next state defined, hsm `%s`", h.def.name)
+			e := h.event(signal, h.currentState.id, "")
+			e.Phase, e.Err = PhaseDispatch, err
 
-			return fmt.Errorf("transition has no next state defined, hsm `%s`", h.name)
-		}
+			h.watchers().error(e)
+			h.goToErrorState(signal, err)
 
-		nextState = transition.nextStatePtr
+			return err
+		}
 
 		switch transition.kind {
-		case transitionKindInternal:
-			return h.doInternalTransition(nextState, transition, signal)
-		case transitionKindNormal:
-			return h.doNormalTransition(nextState, transition, signal)
+		case TransitionKindInternal:
+			return h.doInternalTransition(transition.nextStatePtr, transition, signal)
+		case TransitionKindNormal:
+			return h.doNormalTransition(transition.nextStatePtr, transition, signal)
 		}
 	}
 
-	return fmt.Errorf("no transition was found from state `%s` and signal `%s`, hsm `%s`", h.currentState.id, h.kind(signal), h.name)
+	err = fmt.Errorf("no transition was found from state `%s` and signal `%s`, hsm `%s`", h.currentState.id, h.kind(signal), h.def.name)
+	e := h.event(signal, h.currentState.id, "")
+	e.Phase, e.Err = PhaseDispatch, err
+
+	h.watchers().signalRejected(e)
+	h.def.logger.Log(LogLevelInfo, "signal rejected", "hsm", h.def.name, "state", h.currentState.id, "signal", e.SignalKind)
+
+	return err
 }
 
 func (h *HSM[C]) doInternalTransition(nextState *Vertex[C], transition *Transition[C], signal Signal) error {
+	var (
+		tx = begin(h)
+		e  = h.transitionEvent(signal, h.currentState.id, nextState.id)
+	)
+
 	// Run transition effect (if any)
 	if transition.effect != nil {
-		if err := transition.effect.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
-
-			return err
+		if err := h.step(&tx, PhaseEffect, e, transition.effect.label, transition.effect.method, transition.effect.compensation, signal); err != nil {
+			return h.abort(&tx, e, signal, err)
 		}
 	}
 
 	// Record in history this successfully applied signal
-	h.signalsHistory = append(h.signalsHistory, h.kind(signal))
+	if h.timed() {
+		e.Duration = time.Since(e.Time)
+	}
+
+	h.record(e, transition, HistoryKindInternal)
+	h.watchers().transitionComplete(e)
 
 	// success
 	return nil
@@ -249,109 +384,327 @@ func (h *HSM[C]) doNormalTransition(nextState *Vertex[C], transition *Transition
 		nextState = nextState.entryState
 	}
 
-	// Run exit actions only if the current state is left (only if it does not return to itself):
-	if h.currentState.onExit != nil {
-		if err := h.currentState.onExit.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
+	var (
+		tx     = begin(h)
+		e      = h.transitionEvent(signal, h.currentState.id, nextState.id)
+		source = h.currentState
+	)
 
-			return err
-		}
+	if h.timed() {
+		e.Dwell = e.Time.Sub(h.enteredAt)
+	}
+
+	// Run exit actions only if the current state is left (only if it does not return to itself):
+	if err := h.exitVertex(&tx, e, source, signal); err != nil {
+		return h.abort(&tx, e, signal, err)
 	}
 
 	// Call the current state's parent state exit action if it has one
 	// and if new parent state is different than the current state's parent
-	if h.currentState.parent != nil &&
-		h.currentState.parent.onExit != nil &&
-		nextState.parent != h.currentState.parent {
-		if err := h.currentState.parent.onExit.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
-
-			return err
+	if source.parent != nil && nextState.parent != source.parent {
+		if err := h.exitVertex(&tx, e, source.parent, signal); err != nil {
+			return h.abort(&tx, e, signal, err)
 		}
 	}
 
 	// Run transition effect (if any)
 	if transition.effect != nil {
-		if err := transition.effect.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
-
-			return err
+		if err := h.step(&tx, PhaseEffect, e, transition.effect.label, transition.effect.method, transition.effect.compensation, signal); err != nil {
+			return h.abort(&tx, e, signal, err)
 		}
 	}
 
 	// Call the new state's parent state entry action if it has one
 	// and if its parent state is different than the current states parent
 	// state
-	if nextState.parent != nil &&
-		nextState.parent.onEntry != nil &&
-		nextState.parent != h.currentState.parent {
-		if err := nextState.parent.onEntry.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
-
-			return err
+	if nextState.parent != nil && nextState.parent != source.parent {
+		if err := h.enterVertex(&tx, e, nextState.parent, signal); err != nil {
+			return h.abort(&tx, e, signal, err)
 		}
 	}
 
 	// Call the new state's entry actions if it has any:
-	if nextState.onEntry != nil {
-		if err := nextState.onEntry.method(h.context, signal); err != nil {
-			h.goToErrorState(signal)
-
-			return err
-		}
+	if err := h.enterVertex(&tx, e, nextState, signal); err != nil {
+		return h.abort(&tx, e, signal, err)
 	}
 
 	h.write(nextState, true)
 
-	if h.currentState == h.errorState {
-		return fmt.Errorf("error state reached, hsm `%s`", h.name)
+	if h.currentState == h.def.errorState {
+		err := fmt.Errorf("error state reached, hsm `%s`", h.def.name)
+		e.Phase, e.Err = PhaseDispatch, err
+
+		h.watchers().error(e)
+
+		return err
 	}
 
 	// Record in history this successfully applied signal
-	h.signalsHistory = append(h.signalsHistory, h.kind(signal))
+	if h.timed() {
+		e.Duration = time.Since(e.Time)
+	}
+
+	if signal == nil {
+		h.record(e, transition, HistoryKindCompletion)
+	} else {
+		h.record(e, transition, HistoryKindNormal)
+	}
+
+	h.watchers().transitionComplete(e)
 
 	// If next state is a choice pseudo-state then evaluate its branches and transition accordingly
-	if h.currentState.kind == vertexKindChoice {
-		return h.apply(nil)
+	if h.currentState.kind == VertexKindChoice {
+		return h.dispatch(nil, true)
+	}
+
+	unconditional, err := h.firstEnabled(nextState.edges.bySignal(nil), nil)
+	if err != nil {
+		return h.guardFailed(nil, err)
 	}
 
-	if unconditional := h.getTransition(nextState, nil); unconditional != nil {
-		return h.apply(nil)
+	if unconditional != nil {
+		// Guards may be fallible or impure, so the transition found is the one taken
+		h.completion = unconditional
+		err = h.dispatch(nil, true)
+		h.completion = nil
+
+		return err
 	}
 
 	// success condition
 	return nil
 }
 
-func (h *HSM[C]) goToErrorState(signal Signal) {
-	h.write(h.errorState, true)
+// exitVertex runs the exit step of the given vertex.
+func (h *HSM[C]) exitVertex(tx *transaction[C], e Event, v *Vertex[C], signal Signal) error {
+	e.Vertex = v.id
+
+	if v.onExit == nil {
+		return h.step(tx, PhaseExit, e, "", nil, nil, signal)
+	}
+
+	return h.step(tx, PhaseExit, e, v.onExit.label, v.onExit.method, v.onExit.compensation, signal)
+}
+
+// enterVertex runs the entry step of the given vertex.
+func (h *HSM[C]) enterVertex(tx *transaction[C], e Event, v *Vertex[C], signal Signal) error {
+	e.Vertex = v.id
+
+	if v.onEntry == nil {
+		return h.step(tx, PhaseEntry, e, "", nil, nil, signal)
+	}
+
+	return h.step(tx, PhaseEntry, e, v.onEntry.label, v.onEntry.method, v.onEntry.compensation, signal)
+}
+
+// step runs a single step of a transition within the given transaction and notifies
+// observers about it. Steps with no method are only notified.
+func (h *HSM[C]) step(tx *transaction[C], phase Phase, e Event, label string, method, compensation ActionFunc[C], signal Signal) error {
+	timed := h.timed()
+
+	e.Phase = phase
+	e.Label = label
+
+	if timed {
+		e.Time = time.Now()
+	}
+
+	if method != nil {
+		var span Span
+		if h.def.tracer != nil {
+			span = h.startSpan(phase, e, label)
+		}
+
+		err := tx.run(h, method, compensation, signal)
+
+		if span != nil {
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			span.End()
+		}
+
+		if err != nil {
+			if timed {
+				e.Duration = time.Since(e.Time)
+			}
+
+			e.Err = err
+
+			h.watchers().error(e)
+
+			return err
+		}
+	}
+
+	if timed {
+		e.Duration = time.Since(e.Time)
+	}
+
+	switch phase {
+	case PhaseExit:
+		h.watchers().exit(e)
+	case PhaseEffect:
+		h.watchers().effect(e)
+	case PhaseEntry:
+		h.watchers().enter(e)
+	}
+
+	return nil
+}
+
+// abort compensates the given failed transaction. The machine is sent to the error
+// state unless it is transactional and the transaction was successfully rolled back, in
+// which case it remains at the transition's source state.
+func (h *HSM[C]) abort(tx *transaction[C], e Event, signal Signal, cause error) error {
+	if err := tx.rollback(h, signal); err != nil {
+		e.Phase, e.Err = PhaseCompensation, err
+
+		joined := errors.Join(cause, err)
+
+		h.watchers().error(e)
+		h.goToErrorState(signal, joined)
+
+		return joined
+	}
+
+	if !h.def.transactional {
+		h.goToErrorState(signal, cause)
+
+		return cause
+	}
+
+	h.def.logger.Log(LogLevelWarn, "transition rolled back",
+		"hsm", h.def.name, "state", h.currentState.id, "signal", h.kind(signal), "error", cause)
+
+	return cause
+}
+
+// microstep accounts for a new transition of the signal being processed, entering the
+// error state once more transitions than allowed were taken.
+func (h *HSM[C]) microstep(signal Signal) error {
+	h.microsteps = append(h.microsteps, h.currentState.id)
+
+	if len(h.microsteps) <= h.def.maxMicrosteps {
+		return nil
+	}
+
+	err := &ErrLivelock{
+		Machine:    h.def.name,
+		Microsteps: h.def.maxMicrosteps,
+		Cycle:      cycleOf(h.microsteps),
+	}
+
+	e := h.event(signal, h.currentState.id, "")
+	e.Phase, e.Err = PhaseDispatch, err
+
+	h.watchers().error(e)
+	h.goToErrorState(signal, err)
+
+	return err
+}
+
+// goToErrorState moves this HSM to its error state because of the given cause.
+func (h *HSM[C]) goToErrorState(signal Signal, cause error) {
+	h.def.logger.Log(LogLevelError, "entering error state",
+		"hsm", h.def.name, "state", h.currentState.id, "signal", h.kind(signal), "error", cause)
+
+	h.write(h.def.errorState, true)
 
 	if s := h.currentState; s != nil && s.onEntry != nil {
 		if err := s.onEntry.method(h.context, signal); err != nil {
-			println("error while entering error state:", err.Error())
+			e := h.event(signal, s.id, s.id)
+			e.Vertex, e.Phase, e.Err = s.id, PhaseEntry, err
+
+			h.watchers().error(e)
+			h.def.logger.Log(LogLevelError, "error while entering error state", "hsm", h.def.name, "error", err)
 		}
 	}
 }
 
-func (h *HSM[C]) getTransition(from *Vertex[C], signal Signal) *Transition[C] {
-	for _, t := range from.edges.bySignal(signal) {
+// getTransition returns the first enabled transition for the given signal from the given
+// state, including those inherited from its parents.
+func (h *HSM[C]) getTransition(from *Vertex[C], signal Signal) (*Transition[C], error) {
+	return h.firstEnabled(from.lookup(signal), signal)
+}
+
+// firstEnabled returns the first transition of the given list matching the given signal
+// whose guard holds, or a guard error if any guard fails.
+func (h *HSM[C]) firstEnabled(transitions []*Transition[C], signal Signal) (*Transition[C], error) {
+	for _, t := range transitions {
+		if !t.matches(signal) {
+			continue
+		}
+
 		if t.guard == nil {
-			return t
+			return t, nil
+		}
+
+		ok, err := h.evaluate(t.source, t, signal)
+		if err != nil {
+			return nil, &GuardError{
+				Machine: h.def.name,
+				State:   h.currentState.id,
+				Signal:  h.kind(signal),
+				Guard:   t.guard.label,
+				Err:     err,
+			}
 		}
 
-		if t.guard.method(h.context) {
-			return t
+		if ok {
+			return t, nil
 		}
 	}
 
-	return nil
+	return nil, nil
+}
+
+// evaluate runs the guard of the given transition, tracing it if required.
+func (h *HSM[C]) evaluate(from *Vertex[C], t *Transition[C], signal Signal) (bool, error) {
+	if h.def.tracer == nil {
+		return t.guard.check(h.context, signal)
+	}
+
+	span := h.def.tracer.Start(h.span, SpanGuard,
+		Attribute{Key: AttributeMachine, Value: h.def.name},
+		Attribute{Key: AttributeSource, Value: from.id},
+		Attribute{Key: AttributeTarget, Value: t.nextStateID},
+		Attribute{Key: AttributeSignal, Value: h.kind(signal)},
+		Attribute{Key: AttributeLabel, Value: t.guard.label},
+	)
+
+	result, err := t.guard.check(h.context, signal)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	span.SetAttributes(Attribute{Key: AttributeGuardResult, Value: result})
+	span.End()
+
+	return result, err
+}
+
+// guardFailed reports the given guard error, entering the error state if required by the
+// guard error policy of this HSM.
+func (h *HSM[C]) guardFailed(signal Signal, err error) error {
+	e := h.event(signal, h.currentState.id, "")
+	e.Phase, e.Err = PhaseGuard, err
+
+	h.watchers().error(e)
+	h.def.logger.Log(LogLevelWarn, "guard failed", "hsm", h.def.name, "state", h.currentState.id, "signal", e.SignalKind, "error", err)
+
+	if h.def.guardErrorPolicy == GuardErrorEnterErrorState {
+		h.goToErrorState(signal, err)
+	}
+
+	return err
 }
 
 // tryProgress forces hsm to progress if nil signal can be triggered.
 func (h *HSM[C]) tryProgress() error {
-	transitions := h.currentState.edges.bySignal(nil)
-	if len(transitions) > 0 {
-		return h.apply(nil)
+	if len(h.currentState.edges.bySignal(nil)) > 0 {
+		return h.dispatch(nil, true)
 	}
 
 	return nil
@@ -359,6 +712,44 @@ func (h *HSM[C]) tryProgress() error {
 
 // kind returns the name of type for the given element.
 func (h *HSM[C]) kind(i interface{}) string {
+	t := reflect.TypeOf(i)
+
+	// types matched by value are comparable, so their values can be looked up
+	if values, ok := h.def.values[t]; ok {
+		if name, ok := values[i]; ok {
+			return name
+		}
+
+		return valueName(i)
+	}
+
+	if name, ok := h.def.kinds[t]; ok {
+		return name
+	}
+
+	return kindOf(i)
+}
+
+// valueName returns the name of the given signal matched by value: named signals and
+// stringers are named after their value, other signals after their type and value, e.g.
+// `DoorEvent(1)`.
+func valueName(value Signal) string {
+	switch v := value.(type) {
+	case Named:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprintf("%s(%v)", kindOf(value), value)
+}
+
+// kindOf returns the name of type for the given element, or the name of named signals.
+func kindOf(i interface{}) string {
+	if name, ok := i.(Named); ok {
+		return string(name)
+	}
+
 	t := reflect.TypeOf(i)
 	if t == nil {
 		return "nil"
@@ -371,11 +762,155 @@ func (h *HSM[C]) kind(i interface{}) string {
 	return t.Name()
 }
 
+// event returns a new event for the given signal, starting now.
+func (h *HSM[C]) event(signal Signal, source, target string) Event {
+	return Event{
+		Machine:    h.def.name,
+		Signal:     signal,
+		SignalKind: h.kind(signal),
+		Source:     source,
+		Target:     target,
+		Time:       time.Now(),
+	}
+}
+
+// transitionEvent returns a new event for a transition of the given signal. It is left
+// empty when nothing observes this HSM, and untimed when only its tracer needs it, so
+// unobserved signals do not pay for it.
+func (h *HSM[C]) transitionEvent(signal Signal, source, target string) Event {
+	if h.timed() {
+		return h.event(signal, source, target)
+	}
+
+	if h.def.tracer == nil {
+		return Event{}
+	}
+
+	return Event{Machine: h.def.name, Signal: signal, SignalKind: h.kind(signal), Source: source, Target: target}
+}
+
+// timed tells whether events of the signal being processed must be timed, as they are
+// notified to observers or recorded in history.
+func (h *HSM[C]) timed() bool {
+	return len(h.notified) > 0 || h.def.historyPolicy.enabled()
+}
+
+// startTrace starts the parent span of the given signal, if tracing is enabled.
+func (h *HSM[C]) startTrace(signal Signal) {
+	if h.def.tracer == nil {
+		return
+	}
+
+	h.span = h.def.tracer.Start(nil, SpanSignal,
+		Attribute{Key: AttributeMachine, Value: h.def.name},
+		Attribute{Key: AttributeSource, Value: h.currentState.id},
+		Attribute{Key: AttributeSignal, Value: h.kind(signal)},
+	)
+}
+
+// startRestoreTrace starts the parent span of the completion steps taken when restoring,
+// if tracing is enabled.
+func (h *HSM[C]) startRestoreTrace() {
+	if h.def.tracer == nil {
+		return
+	}
+
+	h.span = h.def.tracer.Start(nil, SpanRestore,
+		Attribute{Key: AttributeMachine, Value: h.def.name},
+		Attribute{Key: AttributeSource, Value: h.currentState.id},
+	)
+}
+
+// endTrace ends the parent span of the signal being processed, if any.
+func (h *HSM[C]) endTrace(err error) {
+	if h.span == nil {
+		return
+	}
+
+	h.span.SetAttributes(Attribute{Key: AttributeTarget, Value: h.currentState.id})
+
+	if err != nil {
+		h.span.RecordError(err)
+	}
+
+	h.span.End()
+	h.span = nil
+}
+
+// startSpan starts a child span of the signal being processed for the given step.
+func (h *HSM[C]) startSpan(phase Phase, e Event, label string) Span {
+	name := SpanEntry
+
+	switch phase {
+	case PhaseExit:
+		name = SpanExit
+	case PhaseEffect:
+		name = SpanEffect
+	}
+
+	return h.def.tracer.Start(h.span, name,
+		Attribute{Key: AttributeMachine, Value: h.def.name},
+		Attribute{Key: AttributeSource, Value: e.Source},
+		Attribute{Key: AttributeTarget, Value: e.Target},
+		Attribute{Key: AttributeSignal, Value: e.SignalKind},
+		Attribute{Key: AttributeVertex, Value: e.Vertex},
+		Attribute{Key: AttributeLabel, Value: label},
+	)
+}
+
+// watch takes the observers currently registered on this HSM, which are notified about
+// the signal being processed. Observers added or removed meanwhile are only considered
+// from the next signal on.
+func (h *HSM[C]) watch() {
+	h.observersMutex.RLock()
+	defer h.observersMutex.RUnlock()
+
+	h.notified = h.observers
+}
+
+// watchers returns the observers notified about the signal being processed.
+func (h *HSM[C]) watchers() observers {
+	return h.notified
+}
+
+// record registers the transition described by the given event in history.
+func (h *HSM[C]) record(e Event, transition *Transition[C], kind HistoryKind) {
+	if !h.def.historyPolicy.enabled() {
+		return
+	}
+
+	entry := HistoryEntry{
+		Time:     e.Time,
+		Signal:   e.SignalKind,
+		Source:   e.Source,
+		Target:   e.Target,
+		Kind:     kind,
+		Duration: e.Duration,
+	}
+
+	if transition.guard != nil {
+		entry.Guard = transition.guard.label
+	}
+
+	h.currentMutex.Lock()
+	defer h.currentMutex.Unlock()
+
+	h.signalsHistory = append(h.signalsHistory, e.SignalKind)
+	h.history = append(h.history, entry)
+	h.signalsHistory, h.statesHistory, h.statesEnteredAt, h.history = h.def.historyPolicy.retain(
+		h.signalsHistory, h.statesHistory, h.statesEnteredAt, h.history, entry.Time)
+}
+
 // write changes machine state, it is the only point in the code where this occurs.
 func (h *HSM[C]) write(vertex *Vertex[C], log bool) {
-	if log {
-		h.statesHistory = append(h.statesHistory, vertex.id)
-	}
+	h.currentMutex.Lock()
+	defer h.currentMutex.Unlock()
 
 	h.currentState = vertex
+	h.enteredAt = time.Now()
+
+	if log && h.def.historyPolicy.enabled() {
+		h.statesHistory = append(h.statesHistory, vertex.id)
+		h.statesEnteredAt = append(h.statesEnteredAt, h.enteredAt)
+	}
 }
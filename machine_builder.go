@@ -6,17 +6,18 @@ import (
 
 // Builder defines a builder pattern for creating new FSMs.
 type Builder[C any] struct {
-	hsm   *HSM[C]
-	start *Vertex[C]
+	draft       *Definition[C]
+	context     C
+	middlewares []Middleware[C]
 }
 
 // NewBuilder returns a new builder instance.
 func NewBuilder[C any]() *Builder[C] {
 	builder := &Builder[C]{
-		hsm: &HSM[C]{
-			signalsHistory: make([]string, 0),
-			statesHistory:  make([]string, 0),
-			states:         make(map[string]*Vertex[C]),
+		draft: &Definition[C]{
+			states:        make(map[string]*Vertex[C]),
+			logger:        NopLogger(),
+			maxMicrosteps: DefaultMaxMicrosteps,
 		},
 	}
 
@@ -25,28 +26,111 @@ func NewBuilder[C any]() *Builder[C] {
 
 // WithName defines a name for this HSM instance, used for visual representations.
 func (b *Builder[C]) WithName(name string) *Builder[C] {
-	b.hsm.name = name
+	b.draft.name = name
 
 	return b
 }
 
 // WithErrorState registers an error state.
 func (b *Builder[C]) WithErrorState(state *Vertex[C]) *Builder[C] {
-	b.hsm.errorState = state
+	b.draft.errorState = state
+
+	return b
+}
+
+// WithTransactionalTransitions makes the HSM roll back failed transitions: once the
+// completed steps of a failed transition have been compensated, the HSM remains at the
+// transition's source state instead of entering the error state. Contexts implementing
+// `Cloner` are copied before every transition and replaced by that copy on rollback, so
+// the context must be read back using `HSM.Context()` rather than through a pointer kept
+// from the machine creation.
+func (b *Builder[C]) WithTransactionalTransitions() *Builder[C] {
+	b.draft.transactional = true
+
+	return b
+}
+
+// WithGuardErrorPolicy defines how the HSM reacts to failed guards, by default the
+// dispatch is aborted and the HSM remains at its current state.
+func (b *Builder[C]) WithGuardErrorPolicy(policy GuardErrorPolicy) *Builder[C] {
+	b.draft.guardErrorPolicy = policy
+
+	return b
+}
+
+// WithMaxMicrosteps defines how many transitions a single `Signal` call may take,
+// completion transitions and choice branches included, `DefaultMaxMicrosteps` by default.
+// Once exceeded, the HSM enters its error state and returns an `*ErrLivelock`.
+// Non-positive values restore the default.
+func (b *Builder[C]) WithMaxMicrosteps(limit int) *Builder[C] {
+	if limit <= 0 {
+		limit = DefaultMaxMicrosteps
+	}
+
+	b.draft.maxMicrosteps = limit
+
+	return b
+}
+
+// WithObserver registers an observer that will be notified about HSM lifecycle.
+func (b *Builder[C]) WithObserver(observer Observer) *Builder[C] {
+	b.draft.observers = append(b.draft.observers, observer)
+
+	return b
+}
+
+// Use registers middlewares wrapping every dispatch on the HSM, including completion
+// steps. Middlewares are applied in the order they are registered, the first one being
+// the outermost.
+func (b *Builder[C]) Use(middlewares ...Middleware[C]) *Builder[C] {
+	b.middlewares = append(b.middlewares, middlewares...)
+
+	return b
+}
+
+// WithLogger sets the logger used by the HSM for reporting relevant events such as
+// rejected signals or error state entries. Nothing is logged by default.
+func (b *Builder[C]) WithLogger(logger Logger) *Builder[C] {
+	if logger == nil {
+		logger = NopLogger()
+	}
+
+	b.draft.logger = logger
+
+	return b
+}
+
+// WithTracer sets the tracer used by the HSM for creating spans, tracing is disabled
+// by default.
+func (b *Builder[C]) WithTracer(tracer Tracer) *Builder[C] {
+	b.draft.tracer = tracer
+
+	return b
+}
+
+// WithMetrics attaches the given metrics collector to the HSM.
+func (b *Builder[C]) WithMetrics(metrics *Metrics) *Builder[C] {
+	return b.WithObserver(metrics)
+}
+
+// WithHistoryPolicy defines how much history the HSM retains, everything is retained by
+// default.
+func (b *Builder[C]) WithHistoryPolicy(policy HistoryPolicy) *Builder[C] {
+	b.draft.historyPolicy = policy
 
 	return b
 }
 
 // WithContext sets HSM`s context.
 func (b *Builder[C]) WithContext(ctx C) *Builder[C] {
-	b.hsm.context = ctx
+	b.context = ctx
 
 	return b
 }
 
 // StartingAt sets HSM`s starting state.
 func (b *Builder[C]) StartingAt(state *Vertex[C]) *Builder[C] {
-	b.start = state
+	b.draft.start = state
 
 	return b
 }
@@ -59,7 +143,7 @@ func (b *Builder[C]) AddState(state *Vertex[C]) *Builder[C] {
 // AddStates registers multiple states at once.
 func (b *Builder[C]) AddStates(states ...*Vertex[C]) *Builder[C] {
 	for _, s := range states {
-		b.hsm.states[s.id] = s
+		b.draft.states[s.id] = s
 	}
 
 	return b
@@ -68,135 +152,55 @@ func (b *Builder[C]) AddStates(states ...*Vertex[C]) *Builder[C] {
 // Restore builds a new machine instance and restores from the given snapshot.
 // no guards are checked nor entry/exit logic will be executed.
 func (b *Builder[C]) Restore(snapshot Snapshot) (*HSM[C], error) {
-	machine, err := b.Build()
+	def, err := b.Definition()
 	if err != nil {
 		return nil, err
 	}
 
-	if _, ok := machine.states[snapshot.StateID]; !ok {
-		return nil, fmt.Errorf("starting state `%s` does not exists", snapshot.StateID)
-	}
-
-	machine.signalsHistory = snapshot.SignalsHistory
-	machine.statesHistory = snapshot.StatesHistory
-	machine.write(machine.states[snapshot.StateID], false)
+	return def.Restore(b.context, snapshot)
+}
 
-	// force hsm to progress if nil signal can be triggered
-	if err := machine.tryProgress(); err != nil {
+// Build builds the HSM.
+func (b *Builder[C]) Build() (*HSM[C], error) {
+	def, err := b.Definition()
+	if err != nil {
 		return nil, err
 	}
 
-	return machine, nil
+	return def.New(b.context), nil
 }
 
-// Build builds the HSM.
-func (b *Builder[C]) Build() (*HSM[C], error) {
-	if b.hsm.name == "" {
+// Definition compiles and validates a reusable machine definition, from which any number
+// of independent HSM instances can be created. The context given to this builder (if any)
+// is ignored, as each instance is given its own context.
+func (b *Builder[C]) Definition() (*Definition[C], error) {
+	if b.draft.name == "" {
 		return nil, fmt.Errorf("no name was provided fot his HSM")
 	}
 
-	if b.start == nil {
+	if b.draft.start == nil {
 		return nil, fmt.Errorf("no starting state was provided")
 	}
 
-	if b.hsm.errorState == nil {
+	if b.draft.errorState == nil {
 		return nil, fmt.Errorf("no error state was defined")
 	}
 
-	if err := b.validateVertex(b.hsm.errorState); err != nil {
+	def := compile(b.draft, b.middlewares)
+
+	if err := def.validateVertex(def.errorState); err != nil {
 		return nil, err
 	}
 
-	// compute state transitions
-	for _, s := range b.hsm.states {
-		var transitions []*Transition[C]
-		transitions = append(transitions, s.edges.list()...)
-
-		if s.entryState != nil && s.entryState.edges.size() > 0 {
-			transitions = append(transitions, s.entryState.edges.list()...)
-		}
-
-		for _, t := range transitions {
-			if t.kind == transitionKindInternal {
-				t.nextStateID = s.id
-			}
-
-			if _, ok := b.hsm.states[t.nextStateID]; !ok {
-				return nil, fmt.Errorf("state `%s` not found for transition", t.nextStateID)
-			}
-
-			t.nextStatePtr = b.hsm.states[t.nextStateID]
-		}
-
-		if err := b.validateVertex(s); err != nil {
+	for _, s := range def.states {
+		if err := def.validateVertex(s); err != nil {
 			return nil, err
 		}
 	}
 
-	b.hsm.write(b.start, true)
-
-	return b.hsm, nil
-}
-
-// validate ensures the integrity of the given vertex and all its parts
-//
-//nolint:gocyclo
-func (b *Builder[C]) validateVertex(v *Vertex[C]) error {
-	if v.id == "" {
-		return fmt.Errorf("invalid state identity, cannot be empty")
-	}
-
-	if v.parent != nil {
-		if _, ok := b.hsm.states[v.parent.id]; !ok {
-			return fmt.Errorf("invalid state parent, parent state `%s` was not found in this machine", v.parent.id)
-		}
-	}
-
-	if v.onEntry != nil {
-		if v.onEntry.label == "" {
-			return fmt.Errorf("invalid state entry logic, no action label was provided")
-		}
-
-		if v.onEntry.method == nil {
-			return fmt.Errorf("invalid state entry logic, no method was defined")
-		}
-	}
-
-	if v.onExit != nil {
-		if v.onExit.label == "" {
-			return fmt.Errorf("invalid state exit logic, no label was provided")
-		}
-
-		if v.onExit.method == nil {
-			return fmt.Errorf("invalid state exit logic, no method was defined")
-		}
-	}
-
-	for _, t := range v.edges.list() {
-		if t.nextStateID == "" {
-			return fmt.Errorf("invalid transition, no next state was provided")
-		}
-
-		if _, ok := b.hsm.states[t.nextStateID]; !ok {
-			return fmt.Errorf("invalid transition, no next state `%s` does not exists", t.nextStateID)
-		}
-
-		if v.kind == vertexKindFinal {
-			return fmt.Errorf("invalid transition, final states cannot have outgoing transitions")
-		}
-
-		if v.kind == vertexKindError {
-			return fmt.Errorf("invalid transition, error states cannot have outgoing transitions")
-		}
-
-		if t.guard != nil && t.guard.label == "" {
-			return fmt.Errorf("invalid transition, nameless guard provided")
-		}
-
-		if t.effect != nil && t.effect.label == "" {
-			return fmt.Errorf("invalid transition, effects must provide a valid human-readable representation")
-		}
+	if err := def.wire(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return def, nil
 }
@@ -0,0 +1,142 @@
+package hsm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Named is a signal identified by its name, e.g. `hsm.Named("open")`. Unlike any other
+// signal given to `When(...)`, named signals are matched by value, so transitions
+// triggered by `hsm.Named("open")` and `hsm.Named("close")` can coexist on the same state.
+type Named string
+
+// Matcher decides which signals trigger a transition.
+type Matcher interface {
+	// Match reports whether the given signal triggers the transition.
+	Match(signal Signal) bool
+
+	// Label returns a human-readable representation of the matched signals, used by
+	// printers.
+	Label() string
+}
+
+// indexedMatcher is implemented by matchers that only match signals of a single type,
+// which allows dispatch tables to index their transitions by signal type.
+type indexedMatcher interface {
+	Matcher
+
+	// signalType returns the type of every signal matched.
+	signalType() reflect.Type
+
+	// prototype returns a signal matched by this matcher.
+	prototype() Signal
+}
+
+// MatchType returns a matcher for signals having the same type as the given prototype.
+// This is how signals given to `When(...)` are matched, except for named signals.
+func MatchType(prototype Signal) Matcher {
+	return &typeMatcher{proto: prototype, typ: reflect.TypeOf(prototype)}
+}
+
+// MatchValue returns a matcher for signals equal to the given value, such as enum
+// values or named signals.
+func MatchValue[S comparable](value S) Matcher {
+	return &valueMatcher{value: value, typ: reflect.TypeOf(value)}
+}
+
+// MatchInterface returns a matcher for signals assignable to type I, usually an
+// interface implemented by many concrete signals.
+func MatchInterface[I any]() Matcher {
+	return &interfaceMatcher{typ: reflect.TypeOf((*I)(nil)).Elem()}
+}
+
+// MatchFunc returns a matcher using the given predicate, described by the given label.
+// Predicates are never called with nil signals, as completion transitions cannot be
+// triggered by matchers.
+func MatchFunc(label string, predicate func(signal Signal) bool) Matcher {
+	return &funcMatcher{label: label, predicate: predicate}
+}
+
+// matcherFor returns the matcher used for the given `When(...)` signal, nil for
+// completion transitions.
+func matcherFor(signal Signal) Matcher {
+	switch s := signal.(type) {
+	case nil:
+		return nil
+	case Named:
+		return MatchValue(s)
+	default:
+		return MatchType(s)
+	}
+}
+
+// typeMatcher matches signals by type.
+type typeMatcher struct {
+	proto Signal
+	typ   reflect.Type
+}
+
+func (m *typeMatcher) Match(signal Signal) bool {
+	return reflect.TypeOf(signal) == m.typ
+}
+
+func (m *typeMatcher) Label() string {
+	return strings.TrimPrefix(kindOf(m.proto), "*")
+}
+
+func (m *typeMatcher) signalType() reflect.Type {
+	return m.typ
+}
+
+func (m *typeMatcher) prototype() Signal {
+	return m.proto
+}
+
+// valueMatcher matches signals by value.
+type valueMatcher struct {
+	value Signal
+	typ   reflect.Type
+}
+
+func (m *valueMatcher) Match(signal Signal) bool {
+	return reflect.TypeOf(signal) == m.typ && signal == m.value
+}
+
+func (m *valueMatcher) Label() string {
+	return valueName(m.value)
+}
+
+func (m *valueMatcher) signalType() reflect.Type {
+	return m.typ
+}
+
+func (m *valueMatcher) prototype() Signal {
+	return m.value
+}
+
+// interfaceMatcher matches signals by assignability.
+type interfaceMatcher struct {
+	typ reflect.Type
+}
+
+func (m *interfaceMatcher) Match(signal Signal) bool {
+	return signal != nil && reflect.TypeOf(signal).AssignableTo(m.typ)
+}
+
+func (m *interfaceMatcher) Label() string {
+	return m.typ.Name()
+}
+
+// funcMatcher matches signals using a predicate.
+type funcMatcher struct {
+	label     string
+	predicate func(signal Signal) bool
+}
+
+func (m *funcMatcher) Match(signal Signal) bool {
+	return signal != nil && m.predicate(signal)
+}
+
+func (m *funcMatcher) Label() string {
+	return m.label
+}
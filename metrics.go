@@ -0,0 +1,366 @@
+package hsm
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets, in seconds.
+var (
+	DefaultActionBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5}
+	DefaultStateBuckets  = []float64{.001, .01, .1, 1, 10, 60, 600, 3600, 86400}
+)
+
+// Metrics collects per-machine metrics. A single collector may be attached to any number
+// of machines using `Builder.WithMetrics(...)`, samples are labeled by machine name.
+//
+// Collected metrics are:
+//
+//   - hsm_signals_accepted_total: signals that produced a transition, by signal kind.
+//   - hsm_signals_rejected_total: signals that produced no transition, by signal kind.
+//   - hsm_transitions_total: completed transitions, by source and target state.
+//   - hsm_errors_total: errors, by phase.
+//   - hsm_action_duration_seconds: histogram of action latency, by phase.
+//   - hsm_state_duration_seconds: histogram of time spent at each state before leaving it.
+//
+// Metrics implements `expvar.Var`, so it can be published using `expvar.Publish(...)`,
+// and it is able to write the Prometheus text exposition format using `WritePrometheus`.
+type Metrics struct {
+	NopObserver
+
+	mu         sync.Mutex
+	accepted   *counterVec
+	rejected   *counterVec
+	transition *counterVec
+	errors     *counterVec
+	actions    *histogramVec
+	states     *histogramVec
+}
+
+// NewMetrics returns a new empty metrics collector using the default buckets.
+func NewMetrics() *Metrics {
+	return NewMetricsWithBuckets(DefaultActionBuckets, DefaultStateBuckets)
+}
+
+// NewMetricsWithBuckets returns a new empty metrics collector using the given buckets for
+// action latency and time-in-state histograms, buckets are upper bounds in seconds.
+func NewMetricsWithBuckets(actionBuckets, stateBuckets []float64) *Metrics {
+	return &Metrics{
+		accepted:   newCounterVec("hsm_signals_accepted_total", "Signals that produced a transition.", "machine", "signal"),
+		rejected:   newCounterVec("hsm_signals_rejected_total", "Signals that produced no transition.", "machine", "signal"),
+		transition: newCounterVec("hsm_transitions_total", "Completed transitions.", "machine", "source", "target"),
+		errors:     newCounterVec("hsm_errors_total", "Errors by phase.", "machine", "phase"),
+		actions: newHistogramVec("hsm_action_duration_seconds", "Latency of actions and effects.",
+			actionBuckets, "machine", "phase"),
+		states: newHistogramVec("hsm_state_duration_seconds", "Time spent at a state before leaving it.",
+			stateBuckets, "machine", "state"),
+	}
+}
+
+// OnSignalRejected implements Observer.
+func (m *Metrics) OnSignalRejected(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rejected.inc(e.Machine, e.SignalKind)
+}
+
+// OnExit implements Observer.
+func (m *Metrics) OnExit(e Event) {
+	m.observeAction(e)
+}
+
+// OnEffect implements Observer.
+func (m *Metrics) OnEffect(e Event) {
+	m.observeAction(e)
+}
+
+// OnEnter implements Observer.
+func (m *Metrics) OnEnter(e Event) {
+	m.observeAction(e)
+}
+
+// OnTransitionComplete implements Observer.
+func (m *Metrics) OnTransitionComplete(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.Signal != nil {
+		m.accepted.inc(e.Machine, e.SignalKind)
+	}
+
+	m.transition.inc(e.Machine, e.Source, e.Target)
+
+	if e.Source != e.Target {
+		m.states.observe(e.Dwell, e.Machine, e.Source)
+	}
+}
+
+// OnError implements Observer.
+func (m *Metrics) OnError(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errors.inc(e.Machine, string(e.Phase))
+}
+
+// observeAction records the latency of the action described by the given event.
+func (m *Metrics) observeAction(e Event) {
+	if e.Label == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.actions.observe(e.Duration, e.Machine, string(e.Phase))
+}
+
+// Publish publishes this collector through expvar under the given name. Like
+// `expvar.Publish`, it panics if the name is already registered.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, m)
+}
+
+// String implements expvar.Var by returning a JSON representation of every metric.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]interface{})
+
+	for _, c := range []*counterVec{m.accepted, m.rejected, m.transition, m.errors} {
+		out[c.name] = c.json()
+	}
+
+	for _, h := range []*histogramVec{m.actions, m.states} {
+		out[h.name] = h.json()
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "{}"
+	}
+
+	return string(b)
+}
+
+// WritePrometheus writes every metric using the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf := &strings.Builder{}
+
+	for _, c := range []*counterVec{m.accepted, m.rejected, m.transition, m.errors} {
+		c.prometheus(buf)
+	}
+
+	for _, h := range []*histogramVec{m.actions, m.states} {
+		h.prometheus(buf)
+	}
+
+	_, err := io.WriteString(w, buf.String())
+
+	return err
+}
+
+// counterVec is a set of counters sharing the same name, partitioned by labels.
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]*counter
+}
+
+// counter is a single counter sample.
+type counter struct {
+	labels []string
+	value  uint64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*counter),
+	}
+}
+
+// inc increments the counter having the given label values.
+func (c *counterVec) inc(values ...string) {
+	key := strings.Join(values, "\xff")
+
+	if _, ok := c.values[key]; !ok {
+		c.values[key] = &counter{labels: values}
+	}
+
+	c.values[key].value++
+}
+
+func (c *counterVec) json() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(c.values))
+
+	for _, key := range sortedKeys(c.values) {
+		out = append(out, map[string]interface{}{
+			"labels": labelsMap(c.labels, c.values[key].labels),
+			"value":  c.values[key].value,
+		})
+	}
+
+	return out
+}
+
+func (c *counterVec) prometheus(buf *strings.Builder) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(buf, "%s%s %d\n", c.name, promLabels(c.labels, c.values[key].labels), c.values[key].value)
+	}
+}
+
+// histogramVec is a set of histograms sharing the same name, partitioned by labels.
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	values  map[string]*histogram
+}
+
+// histogram is a single histogram sample, counts are not cumulative.
+type histogram struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &histogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: sorted,
+		values:  make(map[string]*histogram),
+	}
+}
+
+// observe records the given duration in the histogram having the given label values.
+func (h *histogramVec) observe(d time.Duration, values ...string) {
+	key := strings.Join(values, "\xff")
+
+	if _, ok := h.values[key]; !ok {
+		h.values[key] = &histogram{
+			labels: values,
+			counts: make([]uint64, len(h.buckets)),
+		}
+	}
+
+	sample := h.values[key]
+	seconds := d.Seconds()
+
+	for i, upper := range h.buckets {
+		if seconds <= upper {
+			sample.counts[i]++
+
+			break
+		}
+	}
+
+	sample.count++
+	sample.sum += seconds
+}
+
+func (h *histogramVec) json() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(h.values))
+
+	for _, key := range sortedKeys(h.values) {
+		sample := h.values[key]
+		buckets := make(map[string]uint64, len(h.buckets))
+		cumulative := uint64(0)
+
+		for i, upper := range h.buckets {
+			cumulative += sample.counts[i]
+			buckets[formatFloat(upper)] = cumulative
+		}
+
+		out = append(out, map[string]interface{}{
+			"labels":  labelsMap(h.labels, sample.labels),
+			"count":   sample.count,
+			"sum":     sample.sum,
+			"buckets": buckets,
+		})
+	}
+
+	return out
+}
+
+func (h *histogramVec) prometheus(buf *strings.Builder) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+
+	for _, key := range sortedKeys(h.values) {
+		var (
+			sample     = h.values[key]
+			names      = append(append([]string(nil), h.labels...), "le")
+			cumulative = uint64(0)
+		)
+
+		for i, upper := range h.buckets {
+			cumulative += sample.counts[i]
+			values := append(append([]string(nil), sample.labels...), formatFloat(upper))
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, promLabels(names, values), cumulative)
+		}
+
+		values := append(append([]string(nil), sample.labels...), "+Inf")
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, promLabels(names, values), sample.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", h.name, promLabels(h.labels, sample.labels), formatFloat(sample.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", h.name, promLabels(h.labels, sample.labels), sample.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func labelsMap(names, values []string) map[string]string {
+	out := make(map[string]string, len(names))
+	for i, name := range names {
+		out[name] = values[i]
+	}
+
+	return out
+}
+
+func promLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+
+	for i, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(values[i])
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, value)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
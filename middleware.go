@@ -0,0 +1,40 @@
+package hsm
+
+// Dispatch describes a single signal dispatch on a HSM.
+type Dispatch struct {
+	// Signal being dispatched, nil for completion steps
+	Signal Signal
+
+	// Completion is true for steps internally generated by the HSM in order to take
+	// completion transitions (signal-less transitions and choice branches), and false for
+	// signals sent through `HSM.Signal()`
+	Completion bool
+}
+
+// Dispatcher applies a dispatch on the given HSM.
+type Dispatcher[C any] func(h *HSM[C], d Dispatch) error
+
+// Middleware wraps a dispatcher with cross-cutting logic such as authorization,
+// deduplication or tracing. Middlewares may rewrite the dispatch before passing it to the
+// next dispatcher, or short-circuit the chain by not calling it at all.
+//
+// Middlewares are called while the HSM is processing a signal, so they must not send
+// signals to the same HSM.
+type Middleware[C any] func(next Dispatcher[C]) Dispatcher[C]
+
+// chain builds a dispatcher applying the given middlewares in order (first middleware is
+// the outermost one) around the default dispatcher.
+func chain[C any](middlewares []Middleware[C]) Dispatcher[C] {
+	dispatcher := Dispatcher[C](applyDispatch[C])
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		dispatcher = middlewares[i](dispatcher)
+	}
+
+	return dispatcher
+}
+
+// applyDispatch is the innermost dispatcher, it applies the dispatched signal.
+func applyDispatch[C any](h *HSM[C], d Dispatch) error {
+	return h.apply(d.Signal)
+}
@@ -0,0 +1,442 @@
+package hsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Model describes a machine by names, as read from PlantUML state diagrams by
+// `ParsePlantUML`. It mirrors the JSON definition format described by `DefinitionSchema`,
+// and is turned into a machine builder by `BuildModel`.
+type Model struct {
+	Name   string          `json:"name"`
+	Start  string          `json:"start"`
+	Error  ModelErrorState `json:"error"`
+	States []*ModelVertex  `json:"states"`
+}
+
+// ModelErrorState is the error state of a model.
+type ModelErrorState struct {
+	ID      string       `json:"id"`
+	OnEntry ModelActions `json:"onEntry,omitempty"`
+}
+
+// ModelVertex is any vertex of a model but the error state. Kind is one of `state`
+// (default when empty), `start`, `choice`, `entry` or `final`.
+type ModelVertex struct {
+	ID          string             `json:"id"`
+	Kind        string             `json:"kind,omitempty"`
+	Parent      string             `json:"parent,omitempty"`
+	Entry       string             `json:"entry,omitempty"`
+	OnEntry     ModelActions       `json:"onEntry,omitempty"`
+	OnExit      ModelActions       `json:"onExit,omitempty"`
+	Transitions []*ModelTransition `json:"transitions,omitempty"`
+}
+
+// ModelTransition is a transition of a model. Kind is either `normal` (default when
+// empty) or `internal`, the latter having no target.
+type ModelTransition struct {
+	Signal string       `json:"signal,omitempty"`
+	Guard  string       `json:"guard,omitempty"`
+	Effect ModelActions `json:"effect,omitempty"`
+	Target string       `json:"target,omitempty"`
+	Kind   string       `json:"kind,omitempty"`
+}
+
+// ModelActions is a list of action names run in order.
+type ModelActions []string
+
+// UnmarshalJSON decodes either a single action name or a list of action names.
+func (a *ModelActions) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*a = ModelActions{name}
+
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("expected an action name or a list of action names")
+	}
+
+	*a = names
+
+	return nil
+}
+
+// String returns the label of the action running these actions, e.g. `lower; light`.
+func (a ModelActions) String() string {
+	return strings.Join(a, "; ")
+}
+
+// Vertex returns the vertex having the given ID, nil if none.
+func (m *Model) Vertex(id string) *ModelVertex {
+	for _, v := range m.States {
+		if v.ID == id {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// ModelError describes why a model is invalid, pointing at the offending path following
+// the JSON definition format, e.g. `$.states[2].transitions[0].guard`.
+type ModelError struct {
+	Path string
+	Err  error
+}
+
+// Error returns a string representation of the error.
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("model %s: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ModelError) Unwrap() error {
+	return e.Err
+}
+
+// Validate ensures the model is well-formed: every referenced vertex exists and is of the
+// expected kind, and vertices only hold the fields allowed by their kind. Errors are
+// reported as `*ModelError`.
+func (m *Model) Validate() error {
+	if m.Name == "" {
+		return modelErrorf("$.name", "missing name")
+	}
+
+	if m.Error.ID == "" {
+		return modelErrorf("$.error.id", "missing error state id")
+	}
+
+	seen := map[string]bool{m.Error.ID: true}
+
+	for i, v := range m.States {
+		path := fmt.Sprintf("$.states[%d]", i)
+
+		if v.ID == "" {
+			return modelErrorf(path+".id", "missing state id")
+		}
+
+		if seen[v.ID] {
+			return modelErrorf(path+".id", "duplicated state id `%s`", v.ID)
+		}
+
+		seen[v.ID] = true
+	}
+
+	if m.Vertex(m.Start) == nil {
+		return modelErrorf("$.start", "state `%s` not found", m.Start)
+	}
+
+	for i, v := range m.States {
+		if err := m.validateVertex(v, fmt.Sprintf("$.states[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) validateVertex(v *ModelVertex, path string) error {
+	kind := v.kind()
+
+	fields, ok := vertexFields[kind]
+	if !ok {
+		return modelErrorf(path+".kind", "unknown vertex kind `%s`", kind)
+	}
+
+	set := []struct {
+		field string
+		isSet bool
+	}{
+		{"parent", v.Parent != ""},
+		{"entry", v.Entry != ""},
+		{"onEntry", len(v.OnEntry) > 0},
+		{"onExit", len(v.OnExit) > 0},
+		{"transitions", len(v.Transitions) > 0},
+	}
+
+	for _, f := range set {
+		if f.isSet && !contains(fields, f.field) {
+			return modelErrorf(path+"."+f.field, "field `%s` is not allowed for %s vertices", f.field, kind)
+		}
+	}
+
+	if err := m.validateReference(v.Parent, path+".parent", "state"); err != nil {
+		return err
+	}
+
+	if err := m.validateReference(v.Entry, path+".entry", "entry"); err != nil {
+		return err
+	}
+
+	for i, t := range v.Transitions {
+		transitionPath := fmt.Sprintf("%s.transitions[%d]", path, i)
+
+		switch t.Kind {
+		case "", TransitionKindNormal.String():
+			if t.Target == "" {
+				return modelErrorf(transitionPath, "missing required field `target`")
+			}
+
+			if m.Vertex(t.Target) == nil {
+				return modelErrorf(transitionPath+".target", "state `%s` not found", t.Target)
+			}
+		case TransitionKindInternal.String():
+			if t.Target != "" {
+				return modelErrorf(transitionPath+".target", "internal transitions cannot have a target")
+			}
+		default:
+			return modelErrorf(transitionPath+".kind", "unknown transition kind `%s`", t.Kind)
+		}
+	}
+
+	return nil
+}
+
+// validateReference ensures the vertex having the given ID, if any, is of the given kind.
+func (m *Model) validateReference(id, path, kind string) error {
+	if id == "" {
+		return nil
+	}
+
+	v := m.Vertex(id)
+	if v == nil {
+		return modelErrorf(path, "state `%s` not found", id)
+	}
+
+	if v.kind() != kind {
+		return modelErrorf(path, "state `%s` is not of kind %s", id, kind)
+	}
+
+	return nil
+}
+
+// kind returns the kind of this vertex, `state` when empty.
+func (v *ModelVertex) kind() string {
+	if v.Kind == "" {
+		return "state"
+	}
+
+	return v.Kind
+}
+
+// modelBuilder converts a model into vertices.
+type modelBuilder[C any] struct {
+	model    *Model
+	registry *Registry[C]
+	paths    map[string]string
+	vertices map[string]*Vertex[C]
+	building map[string]bool
+}
+
+// BuildModel turns the given model into a machine builder, mapping guard and action names
+// to the guards and actions registered in the given registry, and signal names to the
+// registered signals. Errors are reported as `*ModelError`. The returned builder has no
+// context, which must be provided by the caller.
+//
+// Usage:
+//
+//	model, err := hsm.ParsePlantUML(file)
+//	if err != nil {
+//		return err
+//	}
+//
+//	builder, err := hsm.BuildModel(model, registry)
+//	if err != nil {
+//		return err
+//	}
+//
+//	machine, err := builder.WithContext(ctx).Build()
+func BuildModel[C any](model *Model, registry *Registry[C]) (*Builder[C], error) {
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := &modelBuilder[C]{
+		model:    model,
+		registry: registry,
+		paths:    make(map[string]string, len(model.States)),
+		vertices: make(map[string]*Vertex[C], len(model.States)),
+		building: make(map[string]bool, len(model.States)),
+	}
+
+	for i, v := range model.States {
+		b.paths[v.ID] = fmt.Sprintf("$.states[%d]", i)
+	}
+
+	onEntry, err := b.actions(model.Error.OnEntry, "$.error.onEntry")
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewBuilder[C]().
+		WithName(model.Name).
+		WithErrorState(NewErrorState[C]().WithID(model.Error.ID).OnEntry(onEntry).Build())
+
+	for _, v := range model.States {
+		vertex, err := b.vertex(v)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.AddState(vertex)
+	}
+
+	return builder.StartingAt(b.vertices[model.Start]), nil
+}
+
+// vertex converts the given vertex, after its parent and entry state as vertices are
+// linked by pointer.
+func (b *modelBuilder[C]) vertex(v *ModelVertex) (*Vertex[C], error) {
+	if vertex, ok := b.vertices[v.ID]; ok {
+		return vertex, nil
+	}
+
+	path := b.paths[v.ID]
+
+	if b.building[v.ID] {
+		return nil, modelErrorf(path, "state `%s` is nested within itself", v.ID)
+	}
+
+	b.building[v.ID] = true
+
+	parent, err := b.reference(v.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := b.reference(v.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	onEntry, err := b.actions(v.OnEntry, path+".onEntry")
+	if err != nil {
+		return nil, err
+	}
+
+	onExit, err := b.actions(v.OnExit, path+".onExit")
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := make([]*Transition[C], 0, len(v.Transitions))
+
+	for i, t := range v.Transitions {
+		transition, err := b.transition(t, fmt.Sprintf("%s.transitions[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	var vertex *Vertex[C]
+
+	switch v.kind() {
+	case "start":
+		vertex = NewStart[C]().WithID(v.ID).OnExit(onExit).AddTransitions(transitions...).Build()
+	case "choice":
+		vertex = NewChoice[C]().WithID(v.ID).ParentOf(parent).AddTransitions(transitions...).Build()
+	case "entry":
+		vertex = NewEntryState[C]().WithID(v.ID).OnEntry(onEntry).OnExit(onExit).AddTransitions(transitions...).Build()
+	case "final":
+		vertex = NewFinalState[C]().WithID(v.ID).ParentOf(parent).OnEntry(onEntry).Build()
+	default:
+		vertex = NewState[C]().
+			WithID(v.ID).
+			ParentOf(parent).
+			WithEntryState(entry).
+			OnEntry(onEntry).
+			OnExit(onExit).
+			AddTransitions(transitions...).
+			Build()
+	}
+
+	b.vertices[v.ID] = vertex
+
+	return vertex, nil
+}
+
+// reference returns the vertex having the given ID, nil if empty.
+func (b *modelBuilder[C]) reference(id string) (*Vertex[C], error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	return b.vertex(b.model.Vertex(id))
+}
+
+func (b *modelBuilder[C]) transition(t *ModelTransition, path string) (*Transition[C], error) {
+	var (
+		signal Signal
+		guard  *Guard[C]
+		err    error
+	)
+
+	if t.Signal != "" {
+		if signal, err = b.registry.signal(t.Signal); err != nil {
+			return nil, &ModelError{Path: path + ".signal", Err: err}
+		}
+	}
+
+	if t.Guard != "" {
+		if guard, err = b.registry.guard(t.Guard); err != nil {
+			return nil, &ModelError{Path: path + ".guard", Err: err}
+		}
+	}
+
+	action, err := b.actions(t.Effect, path+".effect")
+	if err != nil {
+		return nil, err
+	}
+
+	var effect *Effect[C]
+	if action != nil {
+		effect = &Effect[C]{label: action.label, method: action.method}
+	}
+
+	if t.Kind == TransitionKindInternal.String() {
+		builder := NewInternalTransition[C]().When(signal).ApplyEffect(effect)
+		if guard != nil {
+			builder.GuardedBy(guard)
+		}
+
+		return builder.Build(), nil
+	}
+
+	builder := NewTransition[C]().When(signal).ApplyEffect(effect).GoTo(t.Target)
+	if guard != nil {
+		builder.GuardedBy(guard)
+	}
+
+	return builder.Build(), nil
+}
+
+// actions returns a single action running the given actions in order, nil if none. Errors
+// point at the offending item of lists.
+func (b *modelBuilder[C]) actions(names ModelActions, path string) (*Action[C], error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	for i, name := range names {
+		if _, err := b.registry.action(name); err != nil {
+			if len(names) > 1 {
+				path = fmt.Sprintf("%s[%d]", path, i)
+			}
+
+			return nil, &ModelError{Path: path, Err: err}
+		}
+	}
+
+	return b.registry.sequence(names)
+}
+
+func modelErrorf(path, format string, args ...interface{}) error {
+	return &ModelError{Path: path, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,159 @@
+package hsm
+
+import "time"
+
+// Phase identifies the step of a run-to-completion step in which something happened.
+type Phase string
+
+// Phases of a run-to-completion step.
+const (
+	PhaseDispatch     Phase = "dispatch"
+	PhaseGuard        Phase = "guard"
+	PhaseExit         Phase = "exit"
+	PhaseEffect       Phase = "effect"
+	PhaseEntry        Phase = "entry"
+	PhaseCompensation Phase = "compensation"
+)
+
+// Event describes something that happened within a HSM, it is passed to observers.
+type Event struct {
+	// Name of the machine where this event happened
+	Machine string
+
+	// Signal being processed, nil for completion transitions
+	Signal Signal
+
+	// Kind of the signal being processed, e.g. `*mySignal`
+	SignalKind string
+
+	// ID of the state the transition starts from
+	Source string
+
+	// ID of the state the transition goes to, empty if unknown
+	Target string
+
+	// ID of the vertex being exited or entered, only for exit and entry events
+	Vertex string
+
+	// Label of the action or effect being run, empty if there is none
+	Label string
+
+	// Phase in which this event happened
+	Phase Phase
+
+	// When this event started
+	Time time.Time
+
+	// How long this event took, zero for instantaneous events
+	Duration time.Duration
+
+	// How long the machine stayed at the source state before leaving it, only for
+	// completed normal transitions
+	Dwell time.Duration
+
+	// Error that caused this event, only for error events
+	Err error
+}
+
+// Observer receives notifications about HSM lifecycle. Observers are called synchronously
+// while the HSM is processing a signal, so they must not send signals to the same HSM.
+//
+// For a signal producing a normal transition, hooks are called in the following order:
+//
+//  1. OnSignalReceived
+//  2. OnExit, once per exited vertex, innermost first
+//  3. OnEffect, only if the transition has an effect
+//  4. OnEnter, once per entered vertex, outermost first
+//  5. OnTransitionComplete
+//
+// Internal transitions only call OnEffect (if any) and OnTransitionComplete. Completion
+// transitions (signal-less transitions and choice branches) taken afterwards repeat
+// steps 2 to 5 with a nil signal. When no transition can be found OnSignalRejected is
+// called instead, and when any step fails OnError is called right after it, before
+// compensations are run. Failed guards call OnError with PhaseGuard, before any exit.
+type Observer interface {
+	OnSignalReceived(e Event)
+	OnSignalRejected(e Event)
+	OnExit(e Event)
+	OnEffect(e Event)
+	OnEnter(e Event)
+	OnTransitionComplete(e Event)
+	OnError(e Event)
+}
+
+// NopObserver implements Observer by ignoring every notification. It is meant to be
+// embedded by observers interested in only a few hooks.
+type NopObserver struct{}
+
+// OnSignalReceived implements Observer.
+func (NopObserver) OnSignalReceived(Event) {}
+
+// OnSignalRejected implements Observer.
+func (NopObserver) OnSignalRejected(Event) {}
+
+// OnExit implements Observer.
+func (NopObserver) OnExit(Event) {}
+
+// OnEffect implements Observer.
+func (NopObserver) OnEffect(Event) {}
+
+// OnEnter implements Observer.
+func (NopObserver) OnEnter(Event) {}
+
+// OnTransitionComplete implements Observer.
+func (NopObserver) OnTransitionComplete(Event) {}
+
+// OnError implements Observer.
+func (NopObserver) OnError(Event) {}
+
+// observers holds the list of observers registered on a machine.
+type observers []Observer
+
+// signalReceived notifies every observer.
+func (os observers) signalReceived(e Event) {
+	for _, o := range os {
+		o.OnSignalReceived(e)
+	}
+}
+
+// signalRejected notifies every observer.
+func (os observers) signalRejected(e Event) {
+	for _, o := range os {
+		o.OnSignalRejected(e)
+	}
+}
+
+// exit notifies every observer.
+func (os observers) exit(e Event) {
+	for _, o := range os {
+		o.OnExit(e)
+	}
+}
+
+// effect notifies every observer.
+func (os observers) effect(e Event) {
+	for _, o := range os {
+		o.OnEffect(e)
+	}
+}
+
+// enter notifies every observer.
+func (os observers) enter(e Event) {
+	for _, o := range os {
+		o.OnEnter(e)
+	}
+}
+
+// transitionComplete notifies every observer.
+func (os observers) transitionComplete(e Event) {
+	for _, o := range os {
+		o.OnTransitionComplete(e)
+	}
+}
+
+// error notifies every observer.
+func (os observers) error(e Event) {
+	for _, o := range os {
+		o.OnError(e)
+	}
+}
@@ -0,0 +1,411 @@
+package hsm
+
+import (
+	"bytes"
+	_ "embed" // definition schema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionSchema is the JSON Schema of the JSON/YAML machine definition format read by
+// `ParseJSON` and `ParseYAML`.
+//
+//go:embed definition.schema.json
+var DefinitionSchema []byte
+
+// DefinitionError describes why a JSON/YAML machine definition could not be loaded,
+// pointing at the offending line and path (e.g. `$.states[2].transitions[0].guard`).
+type DefinitionError struct {
+	Line int
+	Path string
+	Err  error
+}
+
+// Error returns a string representation of the error.
+func (e *DefinitionError) Error() string {
+	return fmt.Sprintf("definition line %d, %s: %s", e.Line, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// definitionParser reads a JSON/YAML definition into a model, keeping track of the line
+// of every path so model errors can point at the offending line.
+type definitionParser struct {
+	lines map[string]int
+}
+
+// vertexFields lists the fields accepted by every vertex kind.
+var vertexFields = map[string][]string{
+	"start":  {"id", "kind", "onExit", "transitions"},
+	"state":  {"id", "kind", "parent", "entry", "onEntry", "onExit", "transitions"},
+	"choice": {"id", "kind", "parent", "transitions"},
+	"entry":  {"id", "kind", "onEntry", "onExit", "transitions"},
+	"final":  {"id", "kind", "parent", "onEntry"},
+}
+
+// ParseJSON parses the given JSON machine definition into a machine builder, see
+// `ParseYAML` for details about the format.
+func ParseJSON[C any](r io.Reader, registry *Registry[C]) (*Builder[C], error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var syntax *json.SyntaxError
+
+	if err := json.Unmarshal(data, new(interface{})); errors.As(err, &syntax) {
+		line := bytes.Count(data[:syntax.Offset], []byte("\n")) + 1
+
+		return nil, &DefinitionError{Line: line, Path: "$", Err: err}
+	} else if err != nil {
+		return nil, &DefinitionError{Line: 1, Path: "$", Err: err}
+	}
+
+	// JSON documents are valid YAML documents, which keep track of lines
+	return ParseYAML(bytes.NewReader(data), registry)
+}
+
+// ParseYAML parses the given YAML machine definition into a machine builder, mapping
+// guard and action names to the guards and actions registered in the given registry, and
+// signal names to the registered signals. The format is described by `DefinitionSchema`:
+//
+//	name: turnstile
+//	start: start
+//	error:
+//	  id: error
+//	  onEntry: alert
+//	states:
+//	  - id: start
+//	    kind: start
+//	    transitions:
+//	      - target: locked
+//	  - id: locked
+//	    onEntry: [lock, beep]
+//	    transitions:
+//	      - signal: coin
+//	        guard: paid
+//	        effect: charge
+//	        target: unlocked
+//	      - signal: kick
+//	        kind: internal
+//	        effect: count
+//	  - id: unlocked
+//	    transitions:
+//	      - signal: push
+//	        target: locked
+//
+// Vertices are of kind `state` (default), `start`, `choice`, `entry` or `final`, states
+// are nested using `parent` and composite states name their entry state using `entry`.
+// Actions and effects are either a single name or a list of names run in order.
+// Transitions without signal are completion transitions, and internal transitions have
+// no target. Errors are reported as `*DefinitionError`, pointing at the offending line
+// and path. The returned builder has no context, which must be provided by the caller.
+//
+// Usage:
+//
+//	builder, err := hsm.ParseYAML(file, registry)
+//	if err != nil {
+//		return err
+//	}
+//
+//	machine, err := builder.WithContext(ctx).Build()
+func ParseYAML[C any](r io.Reader, registry *Registry[C]) (*Builder[C], error) {
+	var document yaml.Node
+
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &DefinitionError{Line: 1, Path: "$", Err: errors.New("empty definition")}
+		}
+
+		return nil, &DefinitionError{Line: 1, Path: "$", Err: err}
+	}
+
+	if len(document.Content) == 0 {
+		return nil, &DefinitionError{Line: 1, Path: "$", Err: errors.New("empty definition")}
+	}
+
+	p := &definitionParser{lines: make(map[string]int)}
+
+	model, err := p.parse(document.Content[0])
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := BuildModel(model, registry)
+
+	var invalid *ModelError
+	if errors.As(err, &invalid) {
+		return nil, &DefinitionError{Line: p.line(invalid.Path), Path: invalid.Path, Err: invalid.Err}
+	}
+
+	return builder, err
+}
+
+func (p *definitionParser) parse(root *yaml.Node) (*Model, error) {
+	fields, err := p.mapping(root, "$", "name", "start", "error", "states")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"name", "start", "error", "states"} {
+		if fields[field] == nil {
+			return nil, p.fail(root, "$", "missing required field `%s`", field)
+		}
+	}
+
+	model := &Model{}
+
+	if model.Name, err = p.string(fields["name"], "$.name"); err != nil {
+		return nil, err
+	}
+
+	if model.Start, err = p.string(fields["start"], "$.start"); err != nil {
+		return nil, err
+	}
+
+	if model.Error, err = p.errorState(fields["error"], "$.error"); err != nil {
+		return nil, err
+	}
+
+	if fields["states"].Kind != yaml.SequenceNode {
+		return nil, p.fail(fields["states"], "$.states", "expected a list of vertices")
+	}
+
+	for i, node := range fields["states"].Content {
+		v, err := p.vertex(node, fmt.Sprintf("$.states[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+
+		model.States = append(model.States, v)
+	}
+
+	return model, nil
+}
+
+// errorState reads the given error state node.
+func (p *definitionParser) errorState(node *yaml.Node, path string) (ModelErrorState, error) {
+	var state ModelErrorState
+
+	fields, err := p.mapping(node, path, "id", "onEntry")
+	if err != nil {
+		return state, err
+	}
+
+	if state.ID, err = p.required(node, fields, path, "id"); err != nil {
+		return state, err
+	}
+
+	state.OnEntry, err = p.actions(fields["onEntry"], path+".onEntry")
+
+	return state, err
+}
+
+// vertex reads the given vertex node. Fields are checked against the vertex kind by
+// `Model.Validate`.
+func (p *definitionParser) vertex(node *yaml.Node, path string) (*ModelVertex, error) {
+	fields, err := p.mapping(node, path, "id", "kind", "parent", "entry", "onEntry", "onExit", "transitions")
+	if err != nil {
+		return nil, err
+	}
+
+	v := &ModelVertex{}
+
+	if v.ID, err = p.required(node, fields, path, "id"); err != nil {
+		return nil, err
+	}
+
+	if v.Kind, err = p.optional(fields, path, "kind"); err != nil {
+		return nil, err
+	}
+
+	if v.Parent, err = p.optional(fields, path, "parent"); err != nil {
+		return nil, err
+	}
+
+	if v.Entry, err = p.optional(fields, path, "entry"); err != nil {
+		return nil, err
+	}
+
+	if v.OnEntry, err = p.actions(fields["onEntry"], path+".onEntry"); err != nil {
+		return nil, err
+	}
+
+	if v.OnExit, err = p.actions(fields["onExit"], path+".onExit"); err != nil {
+		return nil, err
+	}
+
+	if node := fields["transitions"]; node != nil {
+		if node.Kind != yaml.SequenceNode {
+			return nil, p.fail(node, path+".transitions", "expected a list of transitions")
+		}
+
+		for i, item := range node.Content {
+			t, err := p.transition(item, fmt.Sprintf("%s.transitions[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			v.Transitions = append(v.Transitions, t)
+		}
+	}
+
+	return v, nil
+}
+
+func (p *definitionParser) transition(node *yaml.Node, path string) (*ModelTransition, error) {
+	fields, err := p.mapping(node, path, "signal", "guard", "effect", "target", "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ModelTransition{}
+
+	if t.Signal, err = p.optional(fields, path, "signal"); err != nil {
+		return nil, err
+	}
+
+	if t.Guard, err = p.optional(fields, path, "guard"); err != nil {
+		return nil, err
+	}
+
+	if t.Effect, err = p.actions(fields["effect"], path+".effect"); err != nil {
+		return nil, err
+	}
+
+	if t.Target, err = p.optional(fields, path, "target"); err != nil {
+		return nil, err
+	}
+
+	if t.Kind, err = p.optional(fields, path, "kind"); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// actions reads the given action name, or list of action names, nil if absent.
+func (p *definitionParser) actions(node *yaml.Node, path string) (ModelActions, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		name, err := p.string(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return ModelActions{name}, nil
+	}
+
+	if len(node.Content) == 0 {
+		return nil, p.fail(node, path, "expected at least one action")
+	}
+
+	names := make(ModelActions, 0, len(node.Content))
+
+	for i, item := range node.Content {
+		name, err := p.string(item, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// mapping returns the fields of the given mapping node, rejecting unknown ones.
+func (p *definitionParser) mapping(node *yaml.Node, path string, allowed ...string) (map[string]*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, p.fail(node, path, "expected an object")
+	}
+
+	p.lines[path] = node.Line
+	fields := make(map[string]*yaml.Node, len(node.Content)/2)
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if !contains(allowed, key.Value) {
+			return nil, p.fail(key, path, "unknown field `%s`", key.Value)
+		}
+
+		if fields[key.Value] != nil {
+			return nil, p.fail(key, path, "duplicated field `%s`", key.Value)
+		}
+
+		fields[key.Value] = value
+		p.lines[path+"."+key.Value] = value.Line
+	}
+
+	return fields, nil
+}
+
+// required returns the given required string field.
+func (p *definitionParser) required(node *yaml.Node, fields map[string]*yaml.Node, path, field string) (string, error) {
+	if fields[field] == nil {
+		return "", p.fail(node, path, "missing required field `%s`", field)
+	}
+
+	return p.string(fields[field], path+"."+field)
+}
+
+// optional returns the given optional string field, empty if absent.
+func (p *definitionParser) optional(fields map[string]*yaml.Node, path, field string) (string, error) {
+	if fields[field] == nil {
+		return "", nil
+	}
+
+	return p.string(fields[field], path+"."+field)
+}
+
+// string returns the value of the given non-empty string node.
+func (p *definitionParser) string(node *yaml.Node, path string) (string, error) {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" || node.Value == "" {
+		return "", p.fail(node, path, "expected a non-empty string")
+	}
+
+	p.lines[path] = node.Line
+
+	return node.Value, nil
+}
+
+// line returns the line of the given path, or of its closest known ancestor.
+func (p *definitionParser) line(path string) int {
+	for path != "" {
+		if line, ok := p.lines[path]; ok {
+			return line
+		}
+
+		path = path[:strings.LastIndexAny(path, ".[")+1]
+		path = strings.TrimRight(path, ".[")
+	}
+
+	return 1
+}
+
+func (p *definitionParser) fail(node *yaml.Node, path, format string, args ...interface{}) error {
+	return &DefinitionError{Line: node.Line, Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// contains tells whether the given list holds the given value.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
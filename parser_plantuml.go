@@ -0,0 +1,376 @@
+package hsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// plantUMLState matches state declarations, e.g. `state "door open" as open {`.
+	plantUMLState = regexp.MustCompile(`^state\s+(?:"([^"]+)"\s+as\s+(\S+)|(\S+))(?:\s+<<(\w+)>>)?(?:\s+(#\w+))?\s*(\{)?$`)
+
+	// plantUMLTransition matches transitions, e.g. `locked -[#green]-> unlocked : coin`.
+	plantUMLTransition = regexp.MustCompile(`^(\[\*\]|\S+)\s+-[^>]*>\s+(\[\*\]|\S+)\s*(?::\s*(.*))?$`)
+
+	// plantUMLDescription matches state descriptions, e.g. `locked : entry / lock()`.
+	plantUMLDescription = regexp.MustCompile(`^(\S+)\s*:\s*(.*)$`)
+
+	// plantUMLLabel matches transition labels, e.g. `coin [paid] / charge`.
+	plantUMLLabel = regexp.MustCompile(`^([^\[/]*?)\s*(?:\[([^\]]*)\])?\s*(?:/\s*(.*))?$`)
+
+	// plantUMLCaption matches captions printed by `PlantUMLPrinter`, e.g. `HSM oven@idle`.
+	plantUMLCaption = regexp.MustCompile(`^(?:caption|title)\s+HSM\s+([^@]+)@`)
+
+	// plantUMLColor matches creole color tags.
+	plantUMLColor = regexp.MustCompile(`</?color[^>]*>`)
+)
+
+// plantUMLParser reads PlantUML state diagrams into models.
+type plantUMLParser struct {
+	model      *Model
+	aliases    map[string]*ModelVertex
+	ids        map[string]bool
+	targets    map[*ModelTransition]*ModelVertex
+	scopes     []*ModelVertex
+	errorAlias string
+	line       int
+}
+
+// ParsePlantUML parses the given PlantUML state diagram into a model, which is turned
+// into a machine builder by `BuildModel`. Diagrams printed by `PlantUMLPrinter` can be
+// parsed back, so diagrams can be the source of truth of machines.
+//
+// Statements are mapped as follows:
+//
+//   - States are identified by their name, or by their alias when unnamed, e.g. `closed`
+//     for `state closed` and `door open` for `state "door open" as open`. Nested
+//     `state X { }` blocks become child states, and `<<choice>>` states become choices.
+//   - `[*]` is the start state at the top level, otherwise the entry state of its
+//     enclosing state when used as a source, and a final state when used as a target.
+//   - Transition labels read `Signal [guard] / effect`, each part being optional, and
+//     `[else]` guards are omitted.
+//   - `X : entry / a` and `X : exit / b` descriptions set the entry and exit actions of
+//     `X`, other descriptions being internal transitions, e.g. `X : tick / count`.
+//     Actions and effects may list several names separated by `;`.
+//   - The state colored `#Red` becomes the error state, and `caption HSM name@...`
+//     names the model.
+//
+// Comments, `skinparam`, `hide` and other captions or titles are ignored, anything else
+// is rejected with an error reporting its line.
+func ParsePlantUML(r io.Reader) (*Model, error) {
+	p := &plantUMLParser{
+		model:   &Model{},
+		aliases: make(map[string]*ModelVertex),
+		ids:     make(map[string]bool),
+		targets: make(map[*ModelTransition]*ModelVertex),
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		p.line++
+
+		if err := p.read(strings.TrimSpace(scanner.Text())); err != nil {
+			return nil, fmt.Errorf("plantuml line %d: %w", p.line, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p.scopes) > 0 {
+		return nil, fmt.Errorf("plantuml line %d: state `%s` is not closed", p.line, p.scopes[len(p.scopes)-1].ID)
+	}
+
+	if p.model.Error.ID == "" {
+		p.model.Error.ID = p.unique("error")
+	}
+
+	// states may be named after being referenced by transitions
+	for transition, target := range p.targets {
+		transition.Target = target.ID
+	}
+
+	return p.model, nil
+}
+
+func (p *plantUMLParser) read(line string) error {
+	switch {
+	case line == "", strings.HasPrefix(line, "'"), strings.HasPrefix(line, "@"):
+		return nil
+	case strings.HasPrefix(line, "caption "), strings.HasPrefix(line, "title "):
+		if m := plantUMLCaption.FindStringSubmatch(line); m != nil && p.model.Name == "" {
+			p.model.Name = m[1]
+		}
+
+		return nil
+	case strings.HasPrefix(line, "skinparam "), strings.HasPrefix(line, "hide "):
+		return nil
+	case line == "}":
+		if len(p.scopes) == 0 {
+			return fmt.Errorf("unexpected `}`")
+		}
+
+		p.scopes = p.scopes[:len(p.scopes)-1]
+
+		return nil
+	}
+
+	if m := plantUMLState.FindStringSubmatch(line); m != nil {
+		return p.declare(m)
+	}
+
+	if m := plantUMLTransition.FindStringSubmatch(line); m != nil {
+		return p.transition(m[1], m[2], m[3])
+	}
+
+	if m := plantUMLDescription.FindStringSubmatch(line); m != nil {
+		return p.describe(m[1], m[2])
+	}
+
+	return fmt.Errorf("unsupported statement `%s`", line)
+}
+
+// declare handles state declarations.
+func (p *plantUMLParser) declare(m []string) error {
+	id, alias := m[1], m[2]
+	if id == "" {
+		id, alias = m[3], m[3]
+	}
+
+	if m[5] == "#Red" {
+		if p.model.Error.ID != "" {
+			return fmt.Errorf("multiple error states found")
+		}
+
+		if m[6] != "" {
+			return fmt.Errorf("error state `%s` cannot have children", id)
+		}
+
+		p.model.Error.ID = id
+		p.errorAlias = alias
+		p.ids[id] = true
+
+		return nil
+	}
+
+	v := p.aliases[alias]
+
+	switch {
+	case v == nil:
+		if p.ids[id] {
+			return fmt.Errorf("duplicated state id `%s`", id)
+		}
+
+		v = p.add(id, "")
+		p.aliases[alias] = v
+	case v.ID != id:
+		// named after a transition already referenced it by alias
+		if p.ids[id] {
+			return fmt.Errorf("duplicated state id `%s`", id)
+		}
+
+		delete(p.ids, v.ID)
+		p.ids[id] = true
+		v.ID = id
+	}
+
+	v.Parent = p.parent()
+
+	switch m[4] {
+	case "":
+	case "choice":
+		v.Kind = "choice"
+	default:
+		return fmt.Errorf("unsupported stereotype `<<%s>>`", m[4])
+	}
+
+	if m[6] != "" {
+		p.scopes = append(p.scopes, v)
+	}
+
+	return nil
+}
+
+// transition handles transitions.
+func (p *plantUMLParser) transition(from, to, label string) error {
+	source, err := p.resolve(from, true)
+	if err != nil {
+		return err
+	}
+
+	target, err := p.resolve(to, false)
+	if err != nil {
+		return err
+	}
+
+	t, err := p.label(label)
+	if err != nil {
+		return err
+	}
+
+	p.targets[t] = target
+	source.Transitions = append(source.Transitions, t)
+
+	return nil
+}
+
+// describe handles state descriptions: entry/exit actions and internal transitions.
+func (p *plantUMLParser) describe(alias, text string) error {
+	text = strings.TrimSpace(plantUMLColor.ReplaceAllString(text, ""))
+
+	if alias == p.errorAlias && alias != "" {
+		if !strings.HasPrefix(text, "entry /") {
+			return fmt.Errorf("error state `%s` can only have entry actions", p.model.Error.ID)
+		}
+
+		p.model.Error.OnEntry = splitActions(strings.TrimPrefix(text, "entry /"))
+
+		return nil
+	}
+
+	v := p.aliases[alias]
+	if v == nil {
+		return fmt.Errorf("state `%s` not found", alias)
+	}
+
+	switch {
+	case strings.HasPrefix(text, "entry /"):
+		v.OnEntry = splitActions(strings.TrimPrefix(text, "entry /"))
+
+		return nil
+	case strings.HasPrefix(text, "exit /"):
+		v.OnExit = splitActions(strings.TrimPrefix(text, "exit /"))
+
+		return nil
+	}
+
+	t, err := p.label(text)
+	if err != nil {
+		return err
+	}
+
+	t.Kind = TransitionKindInternal.String()
+	v.Transitions = append(v.Transitions, t)
+
+	return nil
+}
+
+// resolve returns the vertex referenced by the given alias within the current scope,
+// `[*]` being the start or entry state of the scope as a source and its final state as
+// a target. Unknown aliases are declared within the current scope.
+func (p *plantUMLParser) resolve(alias string, source bool) (*ModelVertex, error) {
+	if alias == p.errorAlias && alias != "" {
+		return nil, fmt.Errorf("error state `%s` cannot be used in transitions", p.model.Error.ID)
+	}
+
+	if alias != "[*]" {
+		v := p.aliases[alias]
+		if v == nil {
+			v = p.add(alias, "")
+			v.Parent = p.parent()
+			p.aliases[alias] = v
+		}
+
+		return v, nil
+	}
+
+	scope := p.parent()
+	key := "[*]" + scope
+
+	switch {
+	case !source:
+		key += ">"
+	case scope != "":
+		key += "<"
+	}
+
+	if v := p.aliases[key]; v != nil {
+		return v, nil
+	}
+
+	var v *ModelVertex
+
+	switch {
+	case !source:
+		v = p.add(p.unique(strings.TrimSpace(scope+" final")), "final")
+		v.Parent = scope
+	case scope == "":
+		v = p.add(p.unique("start"), "start")
+		p.model.Start = v.ID
+	default:
+		v = p.add(p.unique(scope+" entry"), "entry")
+		p.scopes[len(p.scopes)-1].Entry = v.ID
+	}
+
+	p.aliases[key] = v
+
+	return v, nil
+}
+
+// label parses the given transition label, e.g. `coin [paid] / charge`.
+func (p *plantUMLParser) label(label string) (*ModelTransition, error) {
+	label = strings.TrimSpace(plantUMLColor.ReplaceAllString(label, ""))
+
+	m := plantUMLLabel.FindStringSubmatch(label)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported transition label `%s`", label)
+	}
+
+	t := &ModelTransition{Signal: strings.TrimSpace(m[1]), Guard: strings.TrimSpace(m[2])}
+
+	if t.Guard == "else" {
+		t.Guard = ""
+	}
+
+	if m[3] != "" {
+		t.Effect = splitActions(m[3])
+	}
+
+	return t, nil
+}
+
+// add adds a new vertex to the model.
+func (p *plantUMLParser) add(id, kind string) *ModelVertex {
+	v := &ModelVertex{ID: id, Kind: kind}
+	p.model.States = append(p.model.States, v)
+	p.ids[id] = true
+
+	return v
+}
+
+// parent returns the ID of the state being declared, empty at the top-level scope.
+func (p *plantUMLParser) parent() string {
+	if len(p.scopes) == 0 {
+		return ""
+	}
+
+	return p.scopes[len(p.scopes)-1].ID
+}
+
+// unique returns the given ID, suffixed when already taken.
+func (p *plantUMLParser) unique(id string) string {
+	candidate := id
+	for i := 2; p.ids[candidate]; i++ {
+		candidate = fmt.Sprintf("%s %d", id, i)
+	}
+
+	return candidate
+}
+
+// splitActions splits the given label into action names, e.g. `lower; light`.
+func splitActions(label string) ModelActions {
+	var out ModelActions
+
+	for _, name := range strings.Split(label, ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,499 @@
+package hsm
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SCXMLStartID is the ID of the start pseudo-state of machines parsed from SCXML
+// documents, which leads to the document's initial state.
+const SCXMLStartID = "scxml.initial"
+
+// scxmlElement is a parsed SCXML element, along with the line it was found at.
+type scxmlElement struct {
+	name     string
+	line     int
+	attrs    map[string]string
+	text     string
+	children []*scxmlElement
+}
+
+// scxmlParser converts a tree of SCXML elements into vertices.
+type scxmlParser[C any] struct {
+	registry *Registry[C]
+	builder  *Builder[C]
+	ids      map[string]bool
+}
+
+// ParseSCXML parses the given W3C SCXML document into a machine builder, mapping `cond`
+// expressions to the guards registered in the given registry, `<script>` contents and
+// `<send>` events to the registered actions, and transition events to the registered
+// signals.
+//
+// Elements are mapped as follows:
+//
+//   - The document's initial state is reached from a start pseudo-state identified by
+//     `SCXMLStartID`, so it is entered as the machine receives its first signal.
+//   - `<state>` elements become states, nested following the document, and `<final>`
+//     elements become final states.
+//   - `initial` attributes and `<initial>` elements become entry states, compound states
+//     having neither enter their first child.
+//   - States holding nothing but eventless transitions, at least one of them guarded,
+//     become choice pseudo-states.
+//   - Targetless transitions become internal transitions, and transitions listing several
+//     events become one transition per event.
+//
+// Elements and executable content with no counterpart in this package, such as
+// `<parallel>`, `<history>`, `<datamodel>` or `<assign>`, are rejected with an error
+// reporting their line. The returned builder has no error state nor context, which must
+// be provided by the caller before building. Documents printed by `SCXMLPrinter` hold
+// the error state as a regular `<state>`, so a different ID must be used for the error
+// state given to the builder.
+//
+// Usage:
+//
+//	builder, err := hsm.ParseSCXML(file, registry)
+//	if err != nil {
+//		return err
+//	}
+//
+//	machine, err := builder.WithErrorState(MyErrorState).WithContext(ctx).Build()
+func ParseSCXML[C any](r io.Reader, registry *Registry[C]) (*Builder[C], error) {
+	root, err := readSCXML(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.name != "scxml" {
+		return nil, fmt.Errorf("scxml line %d: unexpected root element <%s>", root.line, root.name)
+	}
+
+	p := &scxmlParser[C]{
+		registry: registry,
+		builder:  NewBuilder[C](),
+		ids:      make(map[string]bool),
+	}
+
+	if name := root.attrs["name"]; name != "" {
+		p.builder.WithName(name)
+	}
+
+	for _, child := range root.children {
+		if child.name != "state" && child.name != "final" {
+			return nil, p.unsupported(child)
+		}
+
+		if _, err := p.vertex(child, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	initial := root.attrs["initial"]
+	if initial == "" {
+		if initial = firstStateID(root); initial == "" {
+			return nil, fmt.Errorf("scxml line %d: no states were defined", root.line)
+		}
+	}
+
+	if strings.ContainsAny(initial, " \t\n") {
+		return nil, fmt.Errorf("scxml line %d: multiple initial states are not supported", root.line)
+	}
+
+	if _, ok := p.builder.draft.states[initial]; !ok {
+		return nil, fmt.Errorf("scxml line %d: initial state `%s` not found", root.line, initial)
+	}
+
+	if p.ids[SCXMLStartID] {
+		return nil, fmt.Errorf("scxml line %d: state id `%s` is reserved", root.line, SCXMLStartID)
+	}
+
+	start := NewStart[C]().
+		WithID(SCXMLStartID).
+		AddTransitions(NewTransition[C]().GoTo(initial).Build()).
+		Build()
+
+	return p.builder.StartingAt(start), nil
+}
+
+// vertex converts the given `<state>` or `<final>` element, along with its descendants,
+// and registers them into the builder.
+func (p *scxmlParser[C]) vertex(el *scxmlElement, parent *Vertex[C]) (*Vertex[C], error) {
+	id := el.attrs["id"]
+	if id == "" {
+		return nil, fmt.Errorf("scxml line %d: <%s> has no id", el.line, el.name)
+	}
+
+	if p.ids[id] {
+		return nil, fmt.Errorf("scxml line %d: duplicated state id `%s`", el.line, id)
+	}
+
+	p.ids[id] = true
+
+	var (
+		onEntry, onExit *Action[C]
+		transitions     []*Transition[C]
+		states          []*scxmlElement
+		initial         *scxmlElement
+	)
+
+	for _, child := range el.children {
+		var err error
+
+		switch child.name {
+		case "onentry":
+			onEntry, err = p.executable(child, onEntry)
+		case "onexit":
+			onExit, err = p.executable(child, onExit)
+		case "transition":
+			var parsed []*Transition[C]
+			parsed, err = p.transitions(child)
+			transitions = append(transitions, parsed...)
+		case "state", "final":
+			states = append(states, child)
+		case "initial":
+			initial = child
+		default:
+			err = p.unsupported(child)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if el.name == "final" {
+		if len(transitions) > 0 || len(states) > 0 || initial != nil || onExit != nil {
+			return nil, fmt.Errorf("scxml line %d: final state `%s` can only hold <onentry> actions", el.line, id)
+		}
+
+		vertex := NewFinalState[C]().WithID(id).ParentOf(parent).OnEntry(onEntry).Build()
+		p.builder.AddState(vertex)
+
+		return vertex, nil
+	}
+
+	if isChoice(el, transitions) {
+		vertex := NewChoice[C]().WithID(id).ParentOf(parent).AddTransitions(transitions...).Build()
+		p.builder.AddState(vertex)
+
+		return vertex, nil
+	}
+
+	builder := NewState[C]().
+		WithID(id).
+		ParentOf(parent).
+		OnEntry(onEntry).
+		OnExit(onExit).
+		AddTransitions(transitions...)
+
+	if len(states) > 0 {
+		entry, err := p.entry(el, initial)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.WithEntryState(entry)
+	} else if initial != nil || el.attrs["initial"] != "" {
+		return nil, fmt.Errorf("scxml line %d: atomic state `%s` cannot have an initial state", el.line, id)
+	}
+
+	vertex := builder.Build()
+	p.builder.AddState(vertex)
+
+	for _, child := range states {
+		if _, err := p.vertex(child, vertex); err != nil {
+			return nil, err
+		}
+	}
+
+	return vertex, nil
+}
+
+// entry returns the entry state of the given compound state, defined either by its
+// `initial` attribute, its `<initial>` element or its first child state.
+func (p *scxmlParser[C]) entry(el *scxmlElement, initial *scxmlElement) (*Vertex[C], error) {
+	id := el.attrs["id"] + ".initial"
+	if p.ids[id] {
+		return nil, fmt.Errorf("scxml line %d: duplicated state id `%s`", el.line, id)
+	}
+
+	p.ids[id] = true
+
+	if initial != nil && el.attrs["initial"] != "" {
+		return nil, fmt.Errorf("scxml line %d: state `%s` has both an initial attribute and an <initial> element", el.line, el.attrs["id"])
+	}
+
+	target := el.attrs["initial"]
+	if target == "" {
+		target = firstStateID(el)
+	}
+
+	transition := NewTransition[C]()
+
+	if initial != nil {
+		if len(initial.children) != 1 || initial.children[0].name != "transition" {
+			return nil, fmt.Errorf("scxml line %d: <initial> must hold a single <transition>", initial.line)
+		}
+
+		t := initial.children[0]
+		if t.attrs["event"] != "" || t.attrs["cond"] != "" {
+			return nil, fmt.Errorf("scxml line %d: initial transitions cannot have events nor conditions", t.line)
+		}
+
+		target = t.attrs["target"]
+
+		effect, err := p.executable(t, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if effect != nil {
+			transition.ApplyEffect(&Effect[C]{label: effect.label, method: effect.method})
+		}
+	}
+
+	if target == "" || strings.ContainsAny(target, " \t\n") {
+		return nil, fmt.Errorf("scxml line %d: state `%s` must have a single initial state", el.line, el.attrs["id"])
+	}
+
+	return NewEntryState[C]().WithID(id).AddTransitions(transition.GoTo(target).Build()).Build(), nil
+}
+
+// transitions converts the given `<transition>` element, one transition per event.
+func (p *scxmlParser[C]) transitions(el *scxmlElement) ([]*Transition[C], error) {
+	target := el.attrs["target"]
+	if strings.ContainsAny(target, " \t\n") {
+		return nil, fmt.Errorf("scxml line %d: transitions with multiple targets are not supported", el.line)
+	}
+
+	if target != "" && el.attrs["type"] == "internal" {
+		return nil, fmt.Errorf("scxml line %d: internal transitions with a target are not supported", el.line)
+	}
+
+	var guard *Guard[C]
+
+	if cond := el.attrs["cond"]; cond != "" {
+		var err error
+		if guard, err = p.registry.guard(cond); err != nil {
+			return nil, fmt.Errorf("scxml line %d: %w", el.line, err)
+		}
+	}
+
+	var effect *Effect[C]
+
+	action, err := p.executable(el, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if action != nil {
+		effect = &Effect[C]{label: action.label, method: action.method}
+	}
+
+	events := strings.Fields(el.attrs["event"])
+	if len(events) == 0 {
+		// eventless transitions are completion transitions
+		events = []string{""}
+	}
+
+	transitions := make([]*Transition[C], 0, len(events))
+
+	for _, event := range events {
+		var signal Signal
+
+		if event != "" {
+			if strings.Contains(event, "*") {
+				return nil, fmt.Errorf("scxml line %d: wildcard event `%s` is not supported", el.line, event)
+			}
+
+			if signal, err = p.registry.signal(event); err != nil {
+				return nil, fmt.Errorf("scxml line %d: %w", el.line, err)
+			}
+		}
+
+		if target == "" {
+			builder := NewInternalTransition[C]().When(signal).ApplyEffect(effect)
+			if guard != nil {
+				builder.GuardedBy(guard)
+			}
+
+			transitions = append(transitions, builder.Build())
+
+			continue
+		}
+
+		builder := NewTransition[C]().When(signal).ApplyEffect(effect).GoTo(target)
+		if guard != nil {
+			builder.GuardedBy(guard)
+		}
+
+		transitions = append(transitions, builder.Build())
+	}
+
+	return transitions, nil
+}
+
+// executable converts the executable content of the given element into a single action
+// running the registered actions in order, appended to the given previous action (if
+// any), as a state may hold multiple `<onentry>` and `<onexit>` elements.
+func (p *scxmlParser[C]) executable(el *scxmlElement, previous *Action[C]) (*Action[C], error) {
+	var names []string
+
+	for _, child := range el.children {
+		var name string
+
+		switch child.name {
+		case "script":
+			if child.attrs["src"] != "" {
+				return nil, fmt.Errorf("scxml line %d: external scripts are not supported", child.line)
+			}
+
+			name = child.text
+		case "send":
+			name = child.attrs["event"]
+		default:
+			return nil, fmt.Errorf("scxml line %d: unsupported executable content <%s>", child.line, child.name)
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("scxml line %d: <%s> must name a registered action", child.line, child.name)
+		}
+
+		if _, err := p.registry.action(name); err != nil {
+			return nil, fmt.Errorf("scxml line %d: %w", child.line, err)
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return previous, nil
+	}
+
+	action, err := p.registry.sequence(names)
+	if err != nil {
+		return nil, fmt.Errorf("scxml line %d: %w", el.line, err)
+	}
+
+	if previous == nil {
+		return action, nil
+	}
+
+	first, second := previous.method, action.method
+
+	return &Action[C]{
+		label: previous.label + "; " + action.label,
+		method: func(ctx C, signal Signal) error {
+			if err := first(ctx, signal); err != nil {
+				return err
+			}
+
+			return second(ctx, signal)
+		},
+	}, nil
+}
+
+// unsupported returns the error reported for elements with no counterpart in this package.
+func (p *scxmlParser[C]) unsupported(el *scxmlElement) error {
+	return fmt.Errorf("scxml line %d: unsupported element <%s>", el.line, el.name)
+}
+
+// isChoice tells whether the given state element represents a choice pseudo-state: it
+// holds nothing but eventless transitions, at least one of them guarded.
+func isChoice[C any](el *scxmlElement, transitions []*Transition[C]) bool {
+	if len(transitions) == 0 || len(transitions) != len(el.children) || el.attrs["initial"] != "" {
+		return false
+	}
+
+	guarded := false
+
+	for _, t := range transitions {
+		if t.matcher != nil || t.kind == TransitionKindInternal {
+			return false
+		}
+
+		guarded = guarded || t.guard != nil
+	}
+
+	return guarded
+}
+
+// firstStateID returns the ID of the first `<state>` or `<final>` child of the given
+// element, empty if none.
+func firstStateID(el *scxmlElement) string {
+	for _, child := range el.children {
+		if child.name == "state" || child.name == "final" {
+			return child.attrs["id"]
+		}
+	}
+
+	return ""
+}
+
+// readSCXML reads the given document into a tree of elements.
+func readSCXML(r io.Reader) (*scxmlElement, error) {
+	decoder := xml.NewDecoder(r)
+
+	var (
+		root  *scxmlElement
+		stack []*scxmlElement
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("scxml: %w", err)
+		}
+
+		line, _ := decoder.InputPos()
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Space != "" && tok.Name.Space != SCXMLNamespace {
+				return nil, fmt.Errorf("scxml line %d: unsupported element <%s:%s>", line, tok.Name.Space, tok.Name.Local)
+			}
+
+			el := &scxmlElement{
+				name:  tok.Name.Local,
+				line:  line,
+				attrs: make(map[string]string, len(tok.Attr)),
+			}
+
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == "" {
+					el.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			}
+
+			stack = append(stack, el)
+		case xml.EndElement:
+			el := stack[len(stack)-1]
+			el.text = strings.TrimSpace(el.text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(tok)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("scxml: empty document")
+	}
+
+	return root, nil
+}
@@ -1,6 +1,7 @@
 package hsm
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -11,6 +12,68 @@ type Printer[C any] interface {
 	Print(hsm *HSM[C]) []byte
 }
 
+// transitionLabel returns the UML label of the given transition, e.g. `signal [guard] / effect`.
+func transitionLabel[C any](from *Vertex[C], t *Transition[C]) string {
+	trigger := ""
+	guard := ""
+	effect := ""
+
+	if t.matcher != nil {
+		trigger = t.matcher.Label()
+	}
+
+	if t.guard != nil {
+		guard = fmt.Sprintf(`[%s]`, t.guard.label)
+	}
+
+	if t.effect != nil {
+		effect = fmt.Sprintf(`/ %s`, t.effect.label)
+	}
+
+	if from.kind == VertexKindChoice && trigger == "" && guard == "" && effect == "" {
+		return "[else]"
+	}
+
+	parts := make([]string, 0, 3)
+
+	for _, part := range []string{trigger, guard, effect} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// prioritized returns the transitions of the given vertex in the order they are tried by
+// this package: guarded transitions go before unguarded ones, see `edgesCollection.insert`.
+// Printers targeting formats that select the first enabled transition must follow it.
+func prioritized[C any](v *Vertex[C]) []*Transition[C] {
+	var guarded, unguarded []*Transition[C]
+
+	for _, t := range v.edges.list() {
+		if t.guard != nil {
+			guarded = append(guarded, t)
+		} else {
+			unguarded = append(unguarded, t)
+		}
+	}
+
+	return append(guarded, unguarded...)
+}
+
+// enabled whether the given transition starts from the given current state of the given
+// HSM and its guard (if any) may hold.
+func enabled[C any](h *HSM[C], current *Vertex[C], t *Transition[C]) bool {
+	for _, possible := range current.edges.list() {
+		if possible == t {
+			return possible.guard == nil || possible.guard.available(h.context)
+		}
+	}
+
+	return false
+}
+
 func fnSignatureString(f interface{}) string {
 	t := reflect.TypeOf(f)
 	if t.Kind() != reflect.Func {
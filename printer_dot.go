@@ -0,0 +1,159 @@
+package hsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DotPrinter provides a Graphviz DOT notation printer. Composite states are rendered as
+// clusters, choice pseudo-states as diamonds, the error state in red and the current
+// state highlighted. Transitions enabled from the current state are drawn in green.
+//
+// Usage:
+//
+//	printer := hsm.NewDotPrinter[*MyContext]()
+//	out := printer.Print(MyMachine)
+//	_ = os.WriteFile("machine.dot", out, 0o644) // dot -Tsvg machine.dot > machine.svg
+type DotPrinter[C any] struct {
+	machine *HSM[C]
+	current *Vertex[C]
+}
+
+// NewDotPrinter returns a new printer.
+func NewDotPrinter[C any]() Printer[C] {
+	return &DotPrinter[C]{}
+}
+
+// Print prints the given HSM.
+func (p *DotPrinter[C]) Print(hsm *HSM[C]) []byte {
+	p.machine = hsm
+	p.current = hsm.Current()
+
+	buf := &strings.Builder{}
+
+	fmt.Fprintf(buf, "digraph %s {\n", dotQuote(hsm.def.name))
+	buf.WriteString("  compound=true;\n")
+	fmt.Fprintf(buf, "  label=%s;\n  labelloc=t;\n", dotQuote(fmt.Sprintf("HSM %s@%s", hsm.def.name, p.current.id)))
+	buf.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, v := range hsm.def.vertices {
+		if v.parent == nil {
+			p.renderVertex(buf, v, "  ")
+		}
+	}
+
+	for _, v := range hsm.def.vertices {
+		for _, t := range v.edges.list() {
+			if t.kind == TransitionKindNormal {
+				p.renderTransition(buf, v, t)
+			}
+		}
+	}
+
+	buf.WriteString("}\n")
+
+	return []byte(buf.String())
+}
+
+func (p *DotPrinter[C]) renderVertex(buf *strings.Builder, v *Vertex[C], indent string) {
+	if len(v.children) == 0 {
+		fmt.Fprintf(buf, "%s%s [%s];\n", indent, dotQuote(v.id), p.attributes(v))
+
+		return
+	}
+
+	fmt.Fprintf(buf, "%ssubgraph %s {\n", indent, dotQuote(p.cluster(v)))
+	fmt.Fprintf(buf, "%s  label=%s;\n", indent, dotLabel(p.lines(v)))
+	fmt.Fprintf(buf, "%s  style=rounded;\n", indent)
+
+	if v == p.current {
+		fmt.Fprintf(buf, "%s  color=blue;\n%s  penwidth=2;\n", indent, indent)
+	}
+
+	// anchor node, edges from and to composite states are clipped at the cluster border
+	fmt.Fprintf(buf, "%s  %s [shape=point, style=invis];\n", indent, dotQuote(v.id))
+
+	for _, c := range v.children {
+		p.renderVertex(buf, c, indent+"  ")
+	}
+
+	fmt.Fprintf(buf, "%s}\n", indent)
+}
+
+func (p *DotPrinter[C]) renderTransition(buf *strings.Builder, v *Vertex[C], t *Transition[C]) {
+	attributes := []string{fmt.Sprintf("label=%s", dotQuote(transitionLabel(v, t)))}
+
+	if enabled(p.machine, p.current, t) {
+		attributes = append(attributes, "color=green", "fontcolor=green")
+	}
+
+	if len(v.children) > 0 {
+		attributes = append(attributes, fmt.Sprintf("ltail=%s", dotQuote(p.cluster(v))))
+	}
+
+	if len(t.nextStatePtr.children) > 0 && t.nextStatePtr != v {
+		attributes = append(attributes, fmt.Sprintf("lhead=%s", dotQuote(p.cluster(t.nextStatePtr))))
+	}
+
+	fmt.Fprintf(buf, "  %s -> %s [%s];\n", dotQuote(v.id), dotQuote(t.nextStatePtr.id), strings.Join(attributes, ", "))
+}
+
+// attributes returns the node attributes of the given simple vertex.
+func (p *DotPrinter[C]) attributes(v *Vertex[C]) string {
+	var attributes []string
+
+	switch v.kind {
+	case VertexKindStart, VertexKindEntry:
+		attributes = append(attributes, `shape=circle`, `style=filled`, `fillcolor=black`, `label=""`, `width=0.2`)
+	case VertexKindFinal:
+		attributes = append(attributes, `shape=doublecircle`, `style=filled`, `fillcolor=black`, `label=""`, `width=0.15`)
+	case VertexKindChoice:
+		attributes = append(attributes, `shape=diamond`, `style=""`, `label=""`, `width=0.3`, `height=0.3`)
+	case VertexKindError:
+		attributes = append(attributes, `style="rounded,filled"`, `color=red`, `fillcolor="#ffcccc"`, "label="+dotLabel(p.lines(v)))
+	default:
+		attributes = append(attributes, "label="+dotLabel(p.lines(v)))
+	}
+
+	if v == p.current {
+		attributes = append(attributes, `color=blue`, `penwidth=2`)
+	}
+
+	return strings.Join(attributes, ", ")
+}
+
+// lines returns the label lines of the given vertex: its ID, entry/exit actions and
+// internal transitions.
+func (p *DotPrinter[C]) lines(v *Vertex[C]) []string {
+	lines := []string{v.id}
+
+	if v.onEntry != nil {
+		lines = append(lines, fmt.Sprintf("entry / %s", v.onEntry))
+	}
+
+	if v.onExit != nil {
+		lines = append(lines, fmt.Sprintf("exit / %s", v.onExit))
+	}
+
+	for _, t := range v.edges.list() {
+		if t.kind == TransitionKindInternal {
+			lines = append(lines, transitionLabel(v, t))
+		}
+	}
+
+	return lines
+}
+
+func (p *DotPrinter[C]) cluster(v *Vertex[C]) string {
+	return "cluster_" + v.id
+}
+
+// dotQuote returns the given text as a DOT quoted string.
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+// dotLabel returns the given lines as a single DOT label.
+func dotLabel(lines []string) string {
+	return dotQuote(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,116 @@
+package hsm
+
+import "encoding/json"
+
+// JSONPrinter provides a JSON printer, exporting the machine graph as plain nodes and
+// edges for frontend visualizers. The current state and the transitions enabled from it
+// are included, so the document can be used to render live diagrams.
+//
+// Output looks like:
+//
+//	{
+//	  "name": "turnstile",
+//	  "current": "locked",
+//	  "nodes": [
+//	    {"id": "locked", "kind": "state", "parent": "booth", "onEntry": "lock()"}
+//	  ],
+//	  "edges": [
+//	    {"source": "locked", "target": "unlocked", "signal": "*coinSignal", "guard": "paid()", "kind": "normal", "enabled": true}
+//	  ]
+//	}
+//
+// Nodes are sorted by ID and edges follow the order in which transitions were added to
+// their source vertex. Completion transitions have no signal.
+//
+// Usage:
+//
+//	printer := hsm.NewJSONPrinter[*MyContext]()
+//	out := printer.Print(MyMachine)
+//	_, _ = w.Write(out)
+type JSONPrinter[C any] struct{}
+
+// NewJSONPrinter returns a new printer.
+func NewJSONPrinter[C any]() Printer[C] {
+	return &JSONPrinter[C]{}
+}
+
+// jsonDocument is the root of documents printed by JSONPrinter.
+type jsonDocument struct {
+	Name    string     `json:"name"`
+	Current string     `json:"current"`
+	Nodes   []jsonNode `json:"nodes"`
+	Edges   []jsonEdge `json:"edges"`
+}
+
+// jsonNode describes a vertex.
+type jsonNode struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Parent  string `json:"parent,omitempty"`
+	Entry   string `json:"entry,omitempty"`
+	OnEntry string `json:"onEntry,omitempty"`
+	OnExit  string `json:"onExit,omitempty"`
+}
+
+// jsonEdge describes a transition.
+type jsonEdge struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Signal  string `json:"signal,omitempty"`
+	Guard   string `json:"guard,omitempty"`
+	Effect  string `json:"effect,omitempty"`
+	Kind    string `json:"kind"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Print prints the given HSM.
+func (p *JSONPrinter[C]) Print(hsm *HSM[C]) []byte {
+	current := hsm.Current()
+	doc := jsonDocument{
+		Name:    hsm.def.name,
+		Current: current.id,
+		Nodes:   make([]jsonNode, 0, len(hsm.def.vertices)),
+		Edges:   make([]jsonEdge, 0),
+	}
+
+	for _, v := range hsm.def.vertices {
+		node := jsonNode{ID: v.id, Kind: v.kind.String()}
+
+		if v.parent != nil {
+			node.Parent = v.parent.id
+		}
+
+		if v.entryState != nil {
+			node.Entry = v.entryState.id
+		}
+
+		if v.onEntry != nil {
+			node.OnEntry = v.onEntry.String()
+		}
+
+		if v.onExit != nil {
+			node.OnExit = v.onExit.String()
+		}
+
+		doc.Nodes = append(doc.Nodes, node)
+
+		for _, t := range v.edges.list() {
+			doc.Edges = append(doc.Edges, jsonEdge{
+				Source:  v.id,
+				Target:  t.nextStatePtr.id,
+				Signal:  t.Signal(),
+				Guard:   t.GuardLabel(),
+				Effect:  t.EffectLabel(),
+				Kind:    t.kind.String(),
+				Enabled: enabled(hsm, current, t),
+			})
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return append(out, '\n')
+}
@@ -0,0 +1,174 @@
+package hsm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MermaidPrinter provides a Mermaid `stateDiagram-v2` notation printer, which is rendered
+// natively by GitHub and GitLab markdown. Output is deterministic, so it can be committed
+// to documentation.
+//
+// Entry and exit actions are rendered as state descriptions, and internal transitions as
+// notes. The error state and the current state are styled using the `error` and
+// `current` classes respectively.
+//
+// Usage:
+//
+//	printer := hsm.NewMermaidPrinter[*MyContext]()
+//	out := printer.Print(MyMachine)
+//	println(string(out))
+type MermaidPrinter[C any] struct {
+	machine *HSM[C]
+	current *Vertex[C]
+	aliases map[*Vertex[C]]string
+}
+
+// NewMermaidPrinter returns a new printer.
+func NewMermaidPrinter[C any]() Printer[C] {
+	return &MermaidPrinter[C]{}
+}
+
+// mermaidInvalid matches characters not allowed within Mermaid state IDs.
+var mermaidInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Print prints the given HSM.
+func (p *MermaidPrinter[C]) Print(hsm *HSM[C]) []byte {
+	p.machine = hsm
+	p.current = hsm.Current()
+	p.aliases = make(map[*Vertex[C]]string, len(hsm.def.vertices))
+
+	taken := make(map[string]bool, len(hsm.def.vertices))
+
+	for _, v := range hsm.def.vertices {
+		alias := mermaidInvalid.ReplaceAllString(v.id, "_")
+		for i := 2; taken[alias]; i++ {
+			alias = fmt.Sprintf("%s_%d", mermaidInvalid.ReplaceAllString(v.id, "_"), i)
+		}
+
+		taken[alias] = true
+		p.aliases[v] = alias
+	}
+
+	buf := &strings.Builder{}
+
+	fmt.Fprintf(buf, "---\ntitle: HSM %s\n---\nstateDiagram-v2\n", hsm.def.name)
+	p.renderScope(buf, nil, "  ")
+
+	buf.WriteString("  classDef error fill:#f99,stroke:#c00\n")
+	buf.WriteString("  classDef current stroke:#00f,stroke-width:3px\n")
+	fmt.Fprintf(buf, "  class %s error\n", p.aliases[hsm.def.errorState])
+
+	if p.current.kind == VertexKindState || p.current.kind == VertexKindError {
+		fmt.Fprintf(buf, "  class %s current\n", p.aliases[p.current])
+	}
+
+	return []byte(buf.String())
+}
+
+// renderScope renders every vertex whose parent is the given one (nil for the top-level
+// scope), followed by the transitions starting from them.
+func (p *MermaidPrinter[C]) renderScope(buf *strings.Builder, parent *Vertex[C], indent string) {
+	scope := p.scope(parent)
+
+	for _, v := range scope {
+		p.renderVertex(buf, v, indent)
+	}
+
+	for _, v := range scope {
+		for _, t := range v.edges.list() {
+			if t.kind == TransitionKindNormal {
+				p.renderTransition(buf, v, t, indent)
+			}
+		}
+	}
+}
+
+func (p *MermaidPrinter[C]) renderVertex(buf *strings.Builder, v *Vertex[C], indent string) {
+	alias := p.aliases[v]
+
+	switch v.kind {
+	case VertexKindStart, VertexKindEntry, VertexKindFinal:
+		return
+	case VertexKindChoice:
+		fmt.Fprintf(buf, "%sstate %s <<choice>>\n", indent, alias)
+
+		return
+	}
+
+	if alias != v.id {
+		fmt.Fprintf(buf, "%sstate \"%s\" as %s\n", indent, strings.ReplaceAll(v.id, `"`, "#quot;"), alias)
+	} else if len(v.children) == 0 {
+		fmt.Fprintf(buf, "%sstate %s\n", indent, alias)
+	}
+
+	if len(v.children) > 0 {
+		fmt.Fprintf(buf, "%sstate %s {\n", indent, alias)
+		p.renderScope(buf, v, indent+"  ")
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+
+	if v.onEntry != nil {
+		fmt.Fprintf(buf, "%s%s : entry / %s\n", indent, alias, v.onEntry)
+	}
+
+	if v.onExit != nil {
+		fmt.Fprintf(buf, "%s%s : exit / %s\n", indent, alias, v.onExit)
+	}
+
+	var internals []string
+
+	for _, t := range v.edges.list() {
+		if t.kind == TransitionKindInternal {
+			internals = append(internals, transitionLabel(v, t))
+		}
+	}
+
+	if len(internals) > 0 {
+		fmt.Fprintf(buf, "%snote right of %s\n", indent, alias)
+
+		for _, label := range internals {
+			fmt.Fprintf(buf, "%s  %s\n", indent, label)
+		}
+
+		fmt.Fprintf(buf, "%send note\n", indent)
+	}
+}
+
+func (p *MermaidPrinter[C]) renderTransition(buf *strings.Builder, v *Vertex[C], t *Transition[C], indent string) {
+	from, to := p.aliases[v], p.aliases[t.nextStatePtr]
+
+	if v.kind == VertexKindStart || v.kind == VertexKindEntry {
+		from = "[*]"
+	}
+
+	if t.nextStatePtr.kind == VertexKindFinal && t.nextStatePtr.parent == v.parent {
+		to = "[*]"
+	}
+
+	if label := transitionLabel(v, t); label != "" {
+		fmt.Fprintf(buf, "%s%s --> %s : %s\n", indent, from, to, label)
+
+		return
+	}
+
+	fmt.Fprintf(buf, "%s%s --> %s\n", indent, from, to)
+}
+
+// scope returns the vertices having the given parent, sorted by ID.
+func (p *MermaidPrinter[C]) scope(parent *Vertex[C]) []*Vertex[C] {
+	if parent != nil {
+		return parent.children
+	}
+
+	var roots []*Vertex[C]
+
+	for _, v := range p.machine.def.vertices {
+		if v.parent == nil {
+			roots = append(roots, v)
+		}
+	}
+
+	return roots
+}
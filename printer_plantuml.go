@@ -36,12 +36,12 @@ func (p *PlantUMLPrinter[C]) Print(hsm *HSM[C]) []byte {
 
 func (p *PlantUMLPrinter[C]) init(hsm *HSM[C]) *PlantUMLPrinter[C] {
 	p.machine = hsm
-	for _, s := range p.machine.states {
+	for _, s := range p.machine.def.states {
 		p.ids[s.id] = p.fNV32a(s.id)
 	}
 
 	var (
-		merge  = []*Vertex[C]{p.machine.errorState}
+		merge  = []*Vertex[C]{p.machine.def.errorState}
 		choice []*Vertex[C]
 		entry  []*Vertex[C]
 		start  []*Vertex[C]
@@ -49,15 +49,15 @@ func (p *PlantUMLPrinter[C]) init(hsm *HSM[C]) *PlantUMLPrinter[C] {
 		state  []*Vertex[C]
 	)
 
-	for _, v := range p.machine.states {
+	for _, v := range p.machine.def.states {
 		switch v.kind {
-		case vertexKindChoice:
+		case VertexKindChoice:
 			choice = append(choice, v)
-		case vertexKindStart:
+		case VertexKindStart:
 			start = append(start, v)
-		case vertexKindFinal:
+		case VertexKindFinal:
 			final = append(final, v)
-		case vertexKindState:
+		case VertexKindState:
 			state = append(state, v)
 		}
 
@@ -80,7 +80,7 @@ func (p *PlantUMLPrinter[C]) init(hsm *HSM[C]) *PlantUMLPrinter[C] {
 func (p *PlantUMLPrinter[C]) print() string {
 	var (
 		out     = ""
-		caption = fmt.Sprintf("caption HSM %s@%s\n", p.machine.name, p.machine.Current().id)
+		caption = fmt.Sprintf("caption HSM %s@%s\n", p.machine.def.name, p.machine.Current().id)
 		roots   []*Vertex[C]
 	)
 
@@ -116,19 +116,19 @@ func (p *PlantUMLPrinter[C]) renderVertex(v *Vertex[C]) string {
 	template := ""
 
 	switch v.kind {
-	case vertexKindError:
+	case VertexKindError:
 		template = fmt.Sprintf("state %q as %s #Red\n", v.id, alias)
 		template += "%s"
-	case vertexKindChoice:
-		template = fmt.Sprintf("state %s <<choice>>\n", alias)
+	case VertexKindChoice:
+		template = fmt.Sprintf("state %q as %s <<choice>>\n", v.id, alias)
 		template += "%s\n"
-	case vertexKindEntry:
+	case VertexKindEntry:
 		template += "%s\n"
-	case vertexKindStart:
+	case VertexKindStart:
 		template = "%s\n"
-	case vertexKindFinal:
+	case VertexKindFinal:
 		template = "%s\n"
-	case vertexKindState:
+	case VertexKindState:
 		template = fmt.Sprintf("state %q as %s {\n", v.id, alias)
 		template += "%s\n"
 		template += "}\n"
@@ -159,31 +159,20 @@ func (p *PlantUMLPrinter[C]) renderVertex(v *Vertex[C]) string {
 
 func (p *PlantUMLPrinter[C]) renderTransitionFor(v *Vertex[C], t *Transition[C]) string {
 	var (
-		out          = ""
-		currentState = p.machine.Current()
-		green        bool
-		from         = p.alias(v)
-		to           = p.alias(t.nextStatePtr)
+		out   = ""
+		green = enabled(p.machine, p.machine.Current(), t)
+		from  = p.alias(v)
+		to    = p.alias(t.nextStatePtr)
 	)
 
 	if from == "" && to == "" {
 		return out
 	}
 
-	label := p.renderTransitionLabelFor(v, t)
-
-	for _, possible := range currentState.edges.list() {
-		if possible == t {
-			if possible.guard == nil || possible.guard.method(p.machine.context) {
-				green = true
-
-				break
-			}
-		}
-	}
+	label := transitionLabel(v, t)
 
 	switch t.kind {
-	case transitionKindInternal:
+	case TransitionKindInternal:
 		if label != "" {
 			if green {
 				label = "<color:green>" + label
@@ -193,7 +182,7 @@ func (p *PlantUMLPrinter[C]) renderTransitionFor(v *Vertex[C], t *Transition[C])
 		}
 
 		out = fmt.Sprintf("%s %s\n", from, label)
-	case transitionKindNormal:
+	case TransitionKindNormal:
 		if label != "" {
 			label = " : " + label
 		}
@@ -209,41 +198,17 @@ func (p *PlantUMLPrinter[C]) renderTransitionFor(v *Vertex[C], t *Transition[C])
 	return out
 }
 
-func (p *PlantUMLPrinter[C]) renderTransitionLabelFor(from *Vertex[C], t *Transition[C]) string {
-	trigger := strings.Replace(p.machine.kind(t.signal), "*", "", 1)
-	guard := ""
-	effect := ""
-
-	if t.signal == nil {
-		trigger = ""
-	}
-
-	if t.guard != nil {
-		guard = fmt.Sprintf(`[%s]`, t.guard.label)
-	}
-
-	if t.effect != nil {
-		effect = fmt.Sprintf(`/ %s`, t.effect.label)
-	}
-
-	if from.kind == vertexKindChoice && trigger == "" && guard == "" && effect == "" {
-		return "[else]"
-	}
-
-	return strings.TrimSpace(strings.Join([]string{trigger, guard, effect}, " "))
-}
-
 func (p *PlantUMLPrinter[C]) alias(v *Vertex[C]) string {
 	switch v.kind {
-	case vertexKindEntry:
+	case VertexKindEntry:
 		return "[*]"
-	case vertexKindStart:
+	case VertexKindStart:
 		return "[*]"
-	case vertexKindFinal:
+	case VertexKindFinal:
 		return "[*]"
-	case vertexKindChoice:
+	case VertexKindChoice:
 		return fmt.Sprintf("choice_%d", p.ids[v.id])
-	case vertexKindError:
+	case VertexKindError:
 		return fmt.Sprintf("error_%d", p.ids[v.id])
 	}
 
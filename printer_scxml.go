@@ -0,0 +1,193 @@
+package hsm
+
+import (
+	"encoding/xml"
+	"regexp"
+)
+
+// SCXMLNamespace is the namespace of W3C SCXML documents.
+const SCXMLNamespace = "http://www.w3.org/2005/07/scxml"
+
+// SCXMLPrinter provides a W3C SCXML (State Chart XML) printer, for handing machine
+// definitions to tools outside Go. The current state is not printed.
+//
+// Vertices are mapped as follows:
+//
+//   - States and the error state become `<state>` elements, nested following the states
+//     hierarchy, and final states become `<final>` elements.
+//   - Entry states become the `initial` attribute of their composite state, or an
+//     `<initial>` element when their transition has an effect.
+//   - Choice pseudo-states become states holding eventless guarded transitions.
+//   - Internal transitions become targetless transitions.
+//
+// Guard labels become `cond` attributes and action and effect labels become `<script>`
+// placeholders, as neither can be translated. IDs and events are sanitized so they
+// are valid XML names.
+//
+// Usage:
+//
+//	printer := hsm.NewSCXMLPrinter[*MyContext]()
+//	out := printer.Print(MyMachine)
+//	_ = os.WriteFile("machine.scxml", out, 0o644)
+type SCXMLPrinter[C any] struct{}
+
+// NewSCXMLPrinter returns a new printer.
+func NewSCXMLPrinter[C any]() Printer[C] {
+	return &SCXMLPrinter[C]{}
+}
+
+// scxmlInvalid matches characters not allowed within SCXML IDs and event names.
+var scxmlInvalid = regexp.MustCompile(`[^A-Za-z0-9_.\-]`)
+
+// scxmlDocument is the root element of SCXML documents.
+type scxmlDocument struct {
+	XMLName  xml.Name    `xml:"scxml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Version  string      `xml:"version,attr"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Initial  string      `xml:"initial,attr,omitempty"`
+	Children []scxmlNode `xml:",any"`
+}
+
+// scxmlNode is either a `<state>` or a `<final>` element.
+type scxmlNode struct {
+	XMLName     xml.Name
+	ID          string            `xml:"id,attr"`
+	Initial     string            `xml:"initial,attr,omitempty"`
+	InitialNode *scxmlInitial     `xml:"initial,omitempty"`
+	OnEntry     *scxmlExecutable  `xml:"onentry,omitempty"`
+	OnExit      *scxmlExecutable  `xml:"onexit,omitempty"`
+	Transitions []scxmlTransition `xml:"transition"`
+	Children    []scxmlNode       `xml:",any"`
+}
+
+// scxmlInitial is an `<initial>` element.
+type scxmlInitial struct {
+	Transition scxmlTransition `xml:"transition"`
+}
+
+// scxmlExecutable is a block of executable content, such as `<onentry>`.
+type scxmlExecutable struct {
+	Script string `xml:"script,omitempty"`
+}
+
+// scxmlTransition is a `<transition>` element.
+type scxmlTransition struct {
+	Event  string `xml:"event,attr,omitempty"`
+	Cond   string `xml:"cond,attr,omitempty"`
+	Target string `xml:"target,attr,omitempty"`
+	Script string `xml:"script,omitempty"`
+}
+
+// Print prints the given HSM.
+func (p *SCXMLPrinter[C]) Print(hsm *HSM[C]) []byte {
+	doc := scxmlDocument{
+		Xmlns:   SCXMLNamespace,
+		Version: "1.0",
+		Name:    hsm.def.name,
+		Initial: scxmlID(hsm.def.start.id),
+	}
+
+	if start := hsm.def.start; start.kind == VertexKindStart || start.kind == VertexKindEntry {
+		doc.Initial = ""
+
+		if list := start.edges.list(); len(list) > 0 {
+			doc.Initial = scxmlID(list[0].nextStateID)
+		}
+	}
+
+	for _, v := range hsm.def.vertices {
+		if v.parent == nil {
+			if node, ok := p.node(v); ok {
+				doc.Children = append(doc.Children, node)
+			}
+		}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return append([]byte(xml.Header), append(out, '\n')...)
+}
+
+// node returns the element representing the given vertex, false for pseudo-states not
+// represented by an element of their own.
+func (p *SCXMLPrinter[C]) node(v *Vertex[C]) (scxmlNode, bool) {
+	node := scxmlNode{
+		XMLName: xml.Name{Local: "state"},
+		ID:      scxmlID(v.id),
+	}
+
+	switch v.kind {
+	case VertexKindStart, VertexKindEntry:
+		return node, false
+	case VertexKindFinal:
+		node.XMLName.Local = "final"
+	}
+
+	if v.onEntry != nil {
+		node.OnEntry = &scxmlExecutable{Script: v.onEntry.String()}
+	}
+
+	if v.onExit != nil {
+		node.OnExit = &scxmlExecutable{Script: v.onExit.String()}
+	}
+
+	// SCXML selects the first enabled transition in document order
+	for _, t := range prioritized(v) {
+		transition := scxmlTransition{Target: scxmlID(t.nextStateID)}
+
+		if t.matcher != nil {
+			transition.Event = scxmlInvalid.ReplaceAllString(t.matcher.Label(), "_")
+		}
+
+		if t.guard != nil {
+			transition.Cond = t.guard.label
+		}
+
+		if t.effect != nil {
+			transition.Script = t.effect.label
+		}
+
+		if t.kind == TransitionKindInternal {
+			transition.Target = ""
+		}
+
+		node.Transitions = append(node.Transitions, transition)
+	}
+
+	if entry := v.entryState; entry != nil {
+		if list := entry.edges.list(); len(list) > 0 {
+			initial := list[0]
+			node.Initial = scxmlID(initial.nextStateID)
+
+			if initial.effect != nil {
+				node.Initial = ""
+				node.InitialNode = &scxmlInitial{
+					Transition: scxmlTransition{Target: scxmlID(initial.nextStateID), Script: initial.effect.label},
+				}
+			}
+		}
+	}
+
+	for _, c := range v.children {
+		if child, ok := p.node(c); ok {
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	return node, true
+}
+
+// scxmlID returns the given ID as a valid SCXML ID.
+func scxmlID(id string) string {
+	id = scxmlInvalid.ReplaceAllString(id, "_")
+
+	if id == "" || (id[0] >= '0' && id[0] <= '9') || id[0] == '-' || id[0] == '.' {
+		id = "_" + id
+	}
+
+	return id
+}
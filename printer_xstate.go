@@ -0,0 +1,172 @@
+package hsm
+
+import "encoding/json"
+
+// XStatePrinter provides a printer of XState (v4) machine configurations, so machines can
+// be rendered by existing XState visualizers. As configurations describe machines rather
+// than instances, the current state is not printed.
+//
+// Vertices are mapped as follows:
+//
+//   - States become state nodes, nested following the states hierarchy, and final states
+//     become `final` nodes. Every node is given its vertex ID, and transitions target
+//     nodes by ID (e.g. `#locked`).
+//   - Start and entry pseudo-states become the `initial` property of their parent node,
+//     effects of entry transitions are not printed.
+//   - Choice pseudo-states become nodes holding `always` transitions, as do completion
+//     transitions.
+//   - Internal transitions become targetless `internal` transitions.
+//
+// Matcher labels become event names (e.g. `tSignal` for `*tSignal` signals, as printed by
+// the other printers), guard labels become `cond` names, and action and effect labels
+// become action names.
+//
+// Completion transitions are not an exact match for `always` transitions: XState
+// evaluates `always` transitions after every microstep, so a guarded one may be taken
+// later on once its guard holds, while this library only tries completion transitions
+// when their state is entered. Machines whose completion guards depend on context
+// changed by internal transitions or other states may behave differently when simulated.
+//
+// Usage:
+//
+//	printer := hsm.NewXStatePrinter[*MyContext]()
+//	out := printer.Print(MyMachine)
+//	_ = os.WriteFile("machine.json", out, 0o644)
+type XStatePrinter[C any] struct {
+	nodes map[*Vertex[C]]*xstateNode
+}
+
+// NewXStatePrinter returns a new printer.
+func NewXStatePrinter[C any]() Printer[C] {
+	return &XStatePrinter[C]{}
+}
+
+// xstateNode is an XState state node, the machine being the root one.
+type xstateNode struct {
+	ID      string                        `json:"id"`
+	Type    string                        `json:"type,omitempty"`
+	Initial string                        `json:"initial,omitempty"`
+	Entry   []string                      `json:"entry,omitempty"`
+	Exit    []string                      `json:"exit,omitempty"`
+	On      map[string][]xstateTransition `json:"on,omitempty"`
+	Always  []xstateTransition            `json:"always,omitempty"`
+	States  map[string]*xstateNode        `json:"states,omitempty"`
+}
+
+// xstateTransition is an XState transition config.
+type xstateTransition struct {
+	Target   string   `json:"target,omitempty"`
+	Cond     string   `json:"cond,omitempty"`
+	Actions  []string `json:"actions,omitempty"`
+	Internal bool     `json:"internal,omitempty"`
+}
+
+// Print prints the given HSM.
+func (p *XStatePrinter[C]) Print(hsm *HSM[C]) []byte {
+	root := &xstateNode{ID: hsm.def.name}
+	p.nodes = make(map[*Vertex[C]]*xstateNode, len(hsm.def.vertices))
+
+	for _, v := range hsm.def.vertices {
+		if v.parent == nil {
+			p.add(root, v)
+		}
+	}
+
+	if start := hsm.def.start; start.kind == VertexKindStart || start.kind == VertexKindEntry {
+		if list := start.edges.list(); len(list) > 0 {
+			p.initial(root, nil, list[0].nextStatePtr)
+		}
+	} else {
+		p.initial(root, nil, start)
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	return append(out, '\n')
+}
+
+// add adds the node representing the given vertex (if any) to the given parent node.
+func (p *XStatePrinter[C]) add(parent *xstateNode, v *Vertex[C]) {
+	if v.kind == VertexKindStart || v.kind == VertexKindEntry {
+		return
+	}
+
+	node := &xstateNode{ID: v.id}
+
+	if v.kind == VertexKindFinal {
+		node.Type = "final"
+	}
+
+	if v.onEntry != nil {
+		node.Entry = []string{v.onEntry.String()}
+	}
+
+	if v.onExit != nil {
+		node.Exit = []string{v.onExit.String()}
+	}
+
+	// XState takes the first enabled transition of each list
+	for _, t := range prioritized(v) {
+		transition := xstateTransition{Target: "#" + t.nextStatePtr.id, Cond: t.GuardLabel()}
+
+		if t.effect != nil {
+			transition.Actions = []string{t.effect.label}
+		}
+
+		if t.kind == TransitionKindInternal {
+			transition.Target = ""
+			transition.Internal = true
+		}
+
+		if t.matcher == nil {
+			node.Always = append(node.Always, transition)
+
+			continue
+		}
+
+		if node.On == nil {
+			node.On = make(map[string][]xstateTransition)
+		}
+
+		event := t.matcher.Label()
+		node.On[event] = append(node.On[event], transition)
+	}
+
+	p.nodes[v] = node
+
+	for _, c := range v.children {
+		p.add(node, c)
+	}
+
+	if entry := v.entryState; entry != nil {
+		if list := entry.edges.list(); len(list) > 0 {
+			p.initial(node, v, list[0].nextStatePtr)
+		}
+	}
+
+	if parent.States == nil {
+		parent.States = make(map[string]*xstateNode)
+	}
+
+	parent.States[v.id] = node
+}
+
+// initial sets the initial state of the given parent node (representing the given
+// parent vertex, nil for the machine) so the given target is entered: as initial states
+// must be children of their parent, the initial states of the target's ancestors are set
+// along the way, unless they already have one.
+func (p *XStatePrinter[C]) initial(parent *xstateNode, parentVertex, target *Vertex[C]) {
+	for v := target; v != nil && v != parentVertex; v = v.parent {
+		node := parent
+		if v.parent != parentVertex {
+			node = p.nodes[v.parent]
+		}
+
+		if node != nil && (node.Initial == "" || node == parent) {
+			node.Initial = v.id
+		}
+	}
+}
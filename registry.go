@@ -0,0 +1,131 @@
+package hsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Registry maps the names used by declarative machine definitions (e.g. SCXML documents)
+// to Go guards, actions and signals.
+//
+// Usage:
+//
+//	registry := hsm.NewRegistry[*MyContext]().
+//		RegisterSignal("open", &OpenSignal{}).
+//		RegisterGuard("isLocked", func(ctx *MyContext) bool { return ctx.locked }).
+//		RegisterAction("ring", func(ctx *MyContext, signal hsm.Signal) error { return ctx.ring() })
+type Registry[C any] struct {
+	guards  map[string]*Guard[C]
+	actions map[string]ActionFunc[C]
+	signals map[string]Signal
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry[C any]() *Registry[C] {
+	return &Registry[C]{
+		guards:  make(map[string]*Guard[C]),
+		actions: make(map[string]ActionFunc[C]),
+		signals: make(map[string]Signal),
+	}
+}
+
+// RegisterGuard registers a guard under the given name, which is also used as its label.
+func (r *Registry[C]) RegisterGuard(name string, method GuardFunc[C]) *Registry[C] {
+	r.guards[name] = &Guard[C]{label: name, method: method}
+
+	return r
+}
+
+// RegisterSignalGuard registers a signal-aware guard under the given name, which is also
+// used as its label.
+func (r *Registry[C]) RegisterSignalGuard(name string, method SignalGuardFunc[C]) *Registry[C] {
+	r.guards[name] = &Guard[C]{label: name, signalMethod: method}
+
+	return r
+}
+
+// RegisterFallibleGuard registers a fallible guard under the given name, which is also
+// used as its label.
+func (r *Registry[C]) RegisterFallibleGuard(name string, method FallibleGuardFunc[C]) *Registry[C] {
+	r.guards[name] = &Guard[C]{label: name, fallibleMethod: method}
+
+	return r
+}
+
+// RegisterAction registers an action under the given name, registered actions can be used
+// as entry/exit actions and as transition effects.
+func (r *Registry[C]) RegisterAction(name string, method ActionFunc[C]) *Registry[C] {
+	r.actions[name] = method
+
+	return r
+}
+
+// RegisterSignal registers the signal prototype given to `When(...)` for transitions
+// triggered by the given signal name. Use `hsm.Named(name)` for signals matched by name.
+func (r *Registry[C]) RegisterSignal(name string, prototype Signal) *Registry[C] {
+	r.signals[name] = prototype
+
+	return r
+}
+
+// guard returns the guard registered under the given name.
+func (r *Registry[C]) guard(name string) (*Guard[C], error) {
+	if guard, ok := r.guards[name]; ok {
+		return guard, nil
+	}
+
+	return nil, fmt.Errorf("guard `%s` is not registered", name)
+}
+
+// action returns the action registered under the given name.
+func (r *Registry[C]) action(name string) (ActionFunc[C], error) {
+	if action, ok := r.actions[name]; ok {
+		return action, nil
+	}
+
+	return nil, fmt.Errorf("action `%s` is not registered", name)
+}
+
+// signal returns the signal prototype registered under the given name.
+func (r *Registry[C]) signal(name string) (Signal, error) {
+	if signal, ok := r.signals[name]; ok {
+		return signal, nil
+	}
+
+	return nil, fmt.Errorf("signal `%s` is not registered", name)
+}
+
+// sequence returns an action running the actions registered under the given names in
+// order, labeled after them.
+func (r *Registry[C]) sequence(names []string) (*Action[C], error) {
+	methods := make([]ActionFunc[C], 0, len(names))
+
+	for _, name := range names {
+		method, err := r.action(name)
+		if err != nil {
+			return nil, err
+		}
+
+		methods = append(methods, method)
+	}
+
+	action := &Action[C]{label: strings.Join(names, "; ")}
+
+	if len(methods) == 1 {
+		action.method = methods[0]
+
+		return action, nil
+	}
+
+	action.method = func(ctx C, signal Signal) error {
+		for _, method := range methods {
+			if err := method(ctx, signal); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return action, nil
+}
@@ -0,0 +1,50 @@
+package hsm
+
+// Span attribute keys used by HSMs.
+const (
+	AttributeMachine     = "hsm.machine"
+	AttributeSource      = "hsm.source"
+	AttributeTarget      = "hsm.target"
+	AttributeSignal      = "hsm.signal"
+	AttributeVertex      = "hsm.vertex"
+	AttributeLabel       = "hsm.label"
+	AttributeGuardResult = "hsm.guard.result"
+)
+
+// Span names used by HSMs.
+const (
+	SpanSignal  = "hsm.signal"
+	SpanRestore = "hsm.restore"
+	SpanGuard   = "hsm.guard"
+	SpanExit    = "hsm.exit"
+	SpanEffect  = "hsm.effect"
+	SpanEntry   = "hsm.entry"
+)
+
+// Attribute is a key/value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer creates spans, it is modelled after OpenTelemetry tracers so adapting one is
+// straightforward.
+//
+// HSMs start one parent span per signal and per restore, and one child span per evaluated
+// guard and per executed exit, effect and entry step.
+type Tracer interface {
+	// Start starts a new span as a child of the given parent, parent is nil for root spans.
+	Start(parent Span, name string, attributes ...Attribute) Span
+}
+
+// Span represents a single operation within a trace.
+type Span interface {
+	// SetAttributes adds the given attributes to this span.
+	SetAttributes(attributes ...Attribute)
+
+	// RecordError records the given error as the cause of this span failure.
+	RecordError(err error)
+
+	// End completes this span.
+	End()
+}
@@ -0,0 +1,121 @@
+package hsm
+
+import (
+	"sync"
+	"time"
+)
+
+// RecordedSpan is a span captured by a Recorder.
+type RecordedSpan struct {
+	// Unique identifier of this span within its recorder, starting at 1
+	ID int
+
+	// Identifier of the parent span, zero for root spans
+	ParentID int
+
+	// Name of this span
+	Name string
+
+	// Attributes describing this span
+	Attributes map[string]interface{}
+
+	// Error recorded on this span, if any
+	Err error
+
+	// When this span started and ended
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns how long this span took.
+func (s RecordedSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Recorder is an in-memory Tracer which keeps every ended span, meant for tests.
+//
+// Usage:
+//
+//	recorder := hsm.NewRecorder()
+//	machine, err := hsm.NewBuilder[*MyContext]().WithTracer(recorder)...
+//	spans := recorder.Spans()
+type Recorder struct {
+	mu    sync.Mutex
+	seq   int
+	spans []RecordedSpan
+}
+
+// NewRecorder returns a new empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start implements Tracer.
+func (r *Recorder) Start(parent Span, name string, attributes ...Attribute) Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+
+	span := &recorderSpan{
+		recorder: r,
+		data: RecordedSpan{
+			ID:         r.seq,
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+			Start:      time.Now(),
+		},
+	}
+
+	if p, ok := parent.(*recorderSpan); ok {
+		span.data.ParentID = p.data.ID
+	}
+
+	span.SetAttributes(attributes...)
+
+	return span
+}
+
+// Spans returns every span ended so far, in the order they were ended.
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RecordedSpan(nil), r.spans...)
+}
+
+// Reset discards every recorded span.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = nil
+}
+
+// recorderSpan is a span being recorded.
+type recorderSpan struct {
+	recorder *Recorder
+	data     RecordedSpan
+}
+
+// SetAttributes implements Span.
+func (s *recorderSpan) SetAttributes(attributes ...Attribute) {
+	for _, a := range attributes {
+		s.data.Attributes[a.Key] = a.Value
+	}
+}
+
+// RecordError implements Span.
+func (s *recorderSpan) RecordError(err error) {
+	s.data.Err = err
+}
+
+// End implements Span.
+func (s *recorderSpan) End() {
+	s.data.End = time.Now()
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	s.recorder.spans = append(s.recorder.spans, s.data)
+}
@@ -0,0 +1,79 @@
+package hsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Cloner may be implemented by HSM contexts that want to be restored to their exact
+// pre-transition value whenever a transition fails halfway, on machines built using
+// `Builder.WithTransactionalTransitions()`.
+//
+// On such machines, a copy is taken before running the steps of every transition. If
+// any of those steps fails, the machine context is replaced by that copy once all the
+// compensating actions have been run. The context instance given to the machine is then
+// no longer the live one, so use `HSM.Context()` for reading the context back after a
+// failed transition.
+type Cloner[C any] interface {
+	// Clone returns an independent copy of the context.
+	Clone() C
+}
+
+// transaction keeps track of the steps completed by a single transition, so they can be
+// compensated in reverse order if a later step of the same transition fails.
+type transaction[C any] struct {
+	backup        C
+	restorable    bool
+	compensations []ActionFunc[C]
+}
+
+// begin opens a new transaction on the given machine. Transactions are returned by value
+// so they can live on the stack of the transition being run. Contexts are only copied by
+// transactional machines.
+func begin[C any](h *HSM[C]) transaction[C] {
+	tx := transaction[C]{}
+
+	if !h.def.transactional {
+		return tx
+	}
+
+	if cloner, ok := any(h.context).(Cloner[C]); ok {
+		tx.backup = cloner.Clone()
+		tx.restorable = true
+	}
+
+	return tx
+}
+
+// run executes the given step, registering its compensation (if any) on success.
+func (tx *transaction[C]) run(h *HSM[C], method, compensation ActionFunc[C], signal Signal) error {
+	if err := method(h.context, signal); err != nil {
+		return err
+	}
+
+	if compensation != nil {
+		tx.compensations = append(tx.compensations, compensation)
+	}
+
+	return nil
+}
+
+// rollback compensates every completed step in reverse order and restores the machine
+// context if it is restorable.
+func (tx *transaction[C]) rollback(h *HSM[C], signal Signal) error {
+	var errs []error
+
+	for i := len(tx.compensations) - 1; i >= 0; i-- {
+		if err := tx.compensations[i](h.context, signal); err != nil {
+			errs = append(errs, fmt.Errorf("compensation failed, hsm `%s`: %w", h.def.name, err))
+		}
+	}
+
+	if tx.restorable {
+		h.currentMutex.Lock()
+		h.context = tx.backup
+		h.currentMutex.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,21 +1,97 @@
 package hsm
 
+// TransitionKind identifies the kind of a transition.
+type TransitionKind int
+
+// Kinds of transitions.
 const (
-	transitionKindNormal = iota
-	transitionKindInternal
+	TransitionKindNormal TransitionKind = iota
+	TransitionKindInternal
 )
 
-// transitionKind private definition of transition types.
-type transitionKind int
+// String returns a human-readable representation of the transition kind.
+func (k TransitionKind) String() string {
+	switch k {
+	case TransitionKindNormal:
+		return "normal"
+	case TransitionKindInternal:
+		return "internal"
+	}
+
+	return "unknown"
+}
 
 // Transition represents a transition between two states within a HSM.
 type Transition[C any] struct {
-	kind         transitionKind
+	kind         TransitionKind
 	signal       Signal
+	matcher      Matcher
 	guard        *Guard[C]
 	effect       *Effect[C]
 	nextStateID  string
 	nextStatePtr *Vertex[C]
+	source       *Vertex[C]
+}
+
+// Kind returns the kind of this transition.
+func (t *Transition[C]) Kind() TransitionKind {
+	return t.kind
+}
+
+// Signal returns the kind of the signals triggering this transition, e.g. `*mySignal`,
+// the name of the signal for signals matched by value, e.g. `open`, or the label of its
+// matcher for matchers not bound to a signal type. Empty for completion transitions.
+func (t *Transition[C]) Signal() string {
+	switch m := t.matcher.(type) {
+	case nil:
+		return ""
+	case *valueMatcher:
+		return valueName(m.value)
+	case indexedMatcher:
+		return kindOf(m.prototype())
+	default:
+		return m.Label()
+	}
+}
+
+// TargetID returns the ID of the state this transition goes to.
+func (t *Transition[C]) TargetID() string {
+	return t.nextStateID
+}
+
+// Target returns the state this transition goes to. Targets are only known by transitions
+// obtained from a compiled machine, e.g. using `HSM.States()`.
+func (t *Transition[C]) Target() *Vertex[C] {
+	return t.nextStatePtr
+}
+
+// GuardLabel returns the label of the guard of this transition, empty if it is not
+// guarded.
+func (t *Transition[C]) GuardLabel() string {
+	if t.guard == nil {
+		return ""
+	}
+
+	return t.guard.label
+}
+
+// EffectLabel returns the label of the effect of this transition, empty if it has none.
+func (t *Transition[C]) EffectLabel() string {
+	if t.effect == nil {
+		return ""
+	}
+
+	return t.effect.label
+}
+
+// matches reports whether the given signal triggers this transition, completion
+// transitions are only triggered by nil signals.
+func (t *Transition[C]) matches(signal Signal) bool {
+	if t.matcher == nil {
+		return signal == nil
+	}
+
+	return t.matcher.Match(signal)
 }
 
 // NewTransition returns a new transition builder.
@@ -3,6 +3,7 @@ package hsm
 // InternalTransitionBuilder provides builder pattern interface for creating new HSM internal transitions.
 type InternalTransitionBuilder[C any] interface {
 	When(signal Signal) InternalTransitionBuilder[C]
+	WhenMatches(matcher Matcher) InternalTransitionBuilder[C]
 	GuardedBy(guard *Guard[C]) InternalTransitionBuilder[C]
 	ApplyEffect(effect *Effect[C]) InternalTransitionBuilder[C]
 	Build() *Transition[C]
@@ -11,14 +12,30 @@ type InternalTransitionBuilder[C any] interface {
 // internalTransitionBuilder private transition builder.
 type internalTransitionBuilder[C any] struct {
 	signal      Signal
+	matcher     Matcher
 	guard       *Guard[C]
 	effect      *Effect[C]
 	nextStateID string
 }
 
-// When indicates which signal activates this transition.
+// When indicates which signal activates this transition. Signals are matched by type,
+// except for named signals which are matched by value.
 func (b *internalTransitionBuilder[C]) When(signal Signal) InternalTransitionBuilder[C] {
 	b.signal = signal
+	b.matcher = matcherFor(signal)
+
+	return b
+}
+
+// WhenMatches indicates this transition is activated by any signal matched by the given
+// matcher.
+func (b *internalTransitionBuilder[C]) WhenMatches(matcher Matcher) InternalTransitionBuilder[C] {
+	b.signal = nil
+	b.matcher = matcher
+
+	if indexed, ok := matcher.(indexedMatcher); ok {
+		b.signal = indexed.prototype()
+	}
 
 	return b
 }
@@ -46,8 +63,9 @@ func (b *internalTransitionBuilder[C]) Build() *Transition[C] {
 	)
 
 	transition := &Transition[C]{
-		kind:        transitionKindInternal,
+		kind:        TransitionKindInternal,
 		signal:      signal,
+		matcher:     b.matcher,
 		guard:       guard,
 		effect:      effect,
 		nextStateID: b.nextStateID,
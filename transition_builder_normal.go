@@ -3,6 +3,7 @@ package hsm
 // TransitionBuilder provides builder pattern interface for creating new HSM regular transitions.
 type TransitionBuilder[C any] interface {
 	When(signal Signal) TransitionBuilder[C]
+	WhenMatches(matcher Matcher) TransitionBuilder[C]
 	GuardedBy(guard *Guard[C]) TransitionBuilder[C]
 	ApplyEffect(effect *Effect[C]) TransitionBuilder[C]
 	GoTo(stateID string) TransitionBuilder[C]
@@ -12,14 +13,30 @@ type TransitionBuilder[C any] interface {
 // transitionBuilder private transition builder.
 type transitionBuilder[C any] struct {
 	signal      Signal
+	matcher     Matcher
 	guard       *Guard[C]
 	effect      *Effect[C]
 	nextStateID string
 }
 
-// When indicates which signal activates this transition.
+// When indicates which signal activates this transition. Signals are matched by type,
+// except for named signals which are matched by value.
 func (b *transitionBuilder[C]) When(signal Signal) TransitionBuilder[C] {
 	b.signal = signal
+	b.matcher = matcherFor(signal)
+
+	return b
+}
+
+// WhenMatches indicates this transition is activated by any signal matched by the given
+// matcher.
+func (b *transitionBuilder[C]) WhenMatches(matcher Matcher) TransitionBuilder[C] {
+	b.signal = nil
+	b.matcher = matcher
+
+	if indexed, ok := matcher.(indexedMatcher); ok {
+		b.signal = indexed.prototype()
+	}
 
 	return b
 }
@@ -54,8 +71,9 @@ func (b *transitionBuilder[C]) Build() *Transition[C] {
 	)
 
 	transition := &Transition[C]{
-		kind:        transitionKindNormal,
+		kind:        TransitionKindNormal,
 		signal:      signal,
+		matcher:     b.matcher,
 		guard:       guard,
 		effect:      effect,
 		nextStateID: b.nextStateID,
@@ -0,0 +1,173 @@
+package hsm
+
+import "reflect"
+
+// TypedGuardFunc is a guard method receiving the triggering signal as S.
+type TypedGuardFunc[C, S any] func(ctx C, signal S) bool
+
+// TypedActionFunc is an effect method receiving the triggering signal as S.
+type TypedActionFunc[C, S any] func(ctx C, signal S) error
+
+// TypedTransitionBuilder provides builder pattern interface for creating new HSM regular
+// transitions triggered by signals of type S.
+type TypedTransitionBuilder[C, S any] interface {
+	GuardedBy(label string, guard TypedGuardFunc[C, S]) TypedTransitionBuilder[C, S]
+	ApplyEffect(label string, effect TypedActionFunc[C, S]) TypedTransitionBuilder[C, S]
+	WithCompensation(compensation TypedActionFunc[C, S]) TypedTransitionBuilder[C, S]
+	GoTo(stateID string) TypedTransitionBuilder[C, S]
+	Build() *Transition[C]
+}
+
+// TypedInternalTransitionBuilder provides builder pattern interface for creating new HSM
+// internal transitions triggered by signals of type S.
+type TypedInternalTransitionBuilder[C, S any] interface {
+	GuardedBy(label string, guard TypedGuardFunc[C, S]) TypedInternalTransitionBuilder[C, S]
+	ApplyEffect(label string, effect TypedActionFunc[C, S]) TypedInternalTransitionBuilder[C, S]
+	WithCompensation(compensation TypedActionFunc[C, S]) TypedInternalTransitionBuilder[C, S]
+	Build() *Transition[C]
+}
+
+// On returns a new builder for transitions triggered by signals of type S, whose guards
+// and effects receive the triggering signal as S. Signals are matched by type, or by
+// assignability when S is an interface.
+//
+// Usage:
+//
+//	hsm.On[*MyContext, *Deposit]().
+//		GuardedBy("amount > 0", func(ctx *MyContext, d *Deposit) bool { return d.Amount > 0 }).
+//		ApplyEffect("deposit()", func(ctx *MyContext, d *Deposit) error { ... }).
+//		GoTo("open").
+//		Build()
+func On[C, S any]() TypedTransitionBuilder[C, S] {
+	return &typedTransitionBuilder[C, S]{kind: TransitionKindNormal}
+}
+
+// OnInternal returns a new builder for internal transitions triggered by signals of type
+// S, whose guards and effects receive the triggering signal as S.
+func OnInternal[C, S any]() TypedInternalTransitionBuilder[C, S] {
+	return &typedInternalTransitionBuilder[C, S]{
+		typedTransitionBuilder: typedTransitionBuilder[C, S]{kind: TransitionKindInternal},
+	}
+}
+
+// typedTransitionBuilder private typed transition builder.
+type typedTransitionBuilder[C, S any] struct {
+	kind         TransitionKind
+	guardLabel   string
+	guard        TypedGuardFunc[C, S]
+	effectLabel  string
+	effect       TypedActionFunc[C, S]
+	compensation TypedActionFunc[C, S]
+	nextStateID  string
+}
+
+// GuardedBy indicates this transition is guarded by the given method.
+func (b *typedTransitionBuilder[C, S]) GuardedBy(label string, guard TypedGuardFunc[C, S]) TypedTransitionBuilder[C, S] {
+	b.guardLabel, b.guard = label, guard
+
+	return b
+}
+
+// ApplyEffect registers an effect for this transition.
+func (b *typedTransitionBuilder[C, S]) ApplyEffect(label string, effect TypedActionFunc[C, S]) TypedTransitionBuilder[C, S] {
+	b.effectLabel, b.effect = label, effect
+
+	return b
+}
+
+// WithCompensation defines a compensating method for the effect of this transition.
+func (b *typedTransitionBuilder[C, S]) WithCompensation(compensation TypedActionFunc[C, S]) TypedTransitionBuilder[C, S] {
+	b.compensation = compensation
+
+	return b
+}
+
+// GoTo defines the next state where to transition to.
+func (b *typedTransitionBuilder[C, S]) GoTo(stateID string) TypedTransitionBuilder[C, S] {
+	b.nextStateID = stateID
+
+	return b
+}
+
+// Build finalizes the building process of this transition.
+func (b *typedTransitionBuilder[C, S]) Build() *Transition[C] {
+	var (
+		zero      S
+		matcher   = MatchType(zero)
+		signal    = Signal(zero)
+		guard     *Guard[C]
+		effect    *Effect[C]
+		signature = reflect.TypeOf((*S)(nil)).Elem()
+	)
+
+	if signature.Kind() == reflect.Interface {
+		matcher, signal = MatchInterface[S](), nil
+	}
+
+	if method := b.guard; method != nil {
+		guard = &Guard[C]{
+			label: b.guardLabel,
+			signalMethod: func(ctx C, signal Signal) bool {
+				s, _ := signal.(S)
+
+				return method(ctx, s)
+			},
+		}
+	}
+
+	if b.effect != nil {
+		effect = &Effect[C]{
+			label:        b.effectLabel,
+			method:       typedAction(b.effect),
+			compensation: typedAction(b.compensation),
+		}
+	}
+
+	return &Transition[C]{
+		kind:        b.kind,
+		signal:      signal,
+		matcher:     matcher,
+		guard:       guard,
+		effect:      effect,
+		nextStateID: b.nextStateID,
+	}
+}
+
+// typedInternalTransitionBuilder private typed internal transition builder.
+type typedInternalTransitionBuilder[C, S any] struct {
+	typedTransitionBuilder[C, S]
+}
+
+// GuardedBy indicates this transition is guarded by the given method.
+func (b *typedInternalTransitionBuilder[C, S]) GuardedBy(label string, guard TypedGuardFunc[C, S]) TypedInternalTransitionBuilder[C, S] {
+	b.typedTransitionBuilder.GuardedBy(label, guard)
+
+	return b
+}
+
+// ApplyEffect registers an effect for this transition.
+func (b *typedInternalTransitionBuilder[C, S]) ApplyEffect(label string, effect TypedActionFunc[C, S]) TypedInternalTransitionBuilder[C, S] {
+	b.typedTransitionBuilder.ApplyEffect(label, effect)
+
+	return b
+}
+
+// WithCompensation defines a compensating method for the effect of this transition.
+func (b *typedInternalTransitionBuilder[C, S]) WithCompensation(compensation TypedActionFunc[C, S]) TypedInternalTransitionBuilder[C, S] {
+	b.typedTransitionBuilder.WithCompensation(compensation)
+
+	return b
+}
+
+// typedAction adapts the given typed method to an action method, nil if no method is given.
+func typedAction[C, S any](method TypedActionFunc[C, S]) ActionFunc[C] {
+	if method == nil {
+		return nil
+	}
+
+	return func(ctx C, signal Signal) error {
+		s, _ := signal.(S)
+
+		return method(ctx, s)
+	}
+}
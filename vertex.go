@@ -2,17 +2,38 @@ package hsm
 
 import "reflect"
 
+// VertexKind identifies the kind of a vertex.
+type VertexKind int
+
+// Kinds of vertices.
 const (
-	vertexKindState = iota
-	vertexKindChoice
-	vertexKindEntry
-	vertexKindStart
-	vertexKindFinal
-	vertexKindError
+	VertexKindState VertexKind = iota
+	VertexKindChoice
+	VertexKindEntry
+	VertexKindStart
+	VertexKindFinal
+	VertexKindError
 )
 
-// vertexKind private definition of vertex kind types.
-type vertexKind int
+// String returns a human-readable representation of the vertex kind.
+func (k VertexKind) String() string {
+	switch k {
+	case VertexKindState:
+		return "state"
+	case VertexKindChoice:
+		return "choice"
+	case VertexKindEntry:
+		return "entry"
+	case VertexKindStart:
+		return "start"
+	case VertexKindFinal:
+		return "final"
+	case VertexKindError:
+		return "error"
+	}
+
+	return "unknown"
+}
 
 // Vertex is named element which is an abstraction of a node in a state machine graph. In general, it can
 // be the source or destination of any number of transitions.
@@ -23,18 +44,37 @@ type vertexKind int
 // - `pseudo-state`.
 type Vertex[C any] struct {
 	id         string
-	kind       vertexKind
+	kind       VertexKind
 	parent     *Vertex[C]
 	entryState *Vertex[C]
 	onEntry    *Action[C]
 	onExit     *Action[C]
 	edges      *edgesCollection[C] // transitions indexed by signal type
+
+	// transitions of this vertex followed by those of its ancestors, indexed by signal
+	// type; precompiled by the definition so dispatching does not walk the hierarchy
+	table map[reflect.Type][]*Transition[C]
+
+	// transitions of this vertex and its ancestors whose matcher cannot be indexed, used
+	// for signal types not found in table
+	fallback []*Transition[C]
+
+	// every indexed transition in table, own transitions first
+	candidates []*Transition[C]
+
+	// vertices having this vertex as parent, sorted by ID
+	children []*Vertex[C]
+
+	// whether this vertex belongs to a definition, which makes it read-only
+	compiled bool
 }
 
 // edgesCollection for handling transitions.
 type edgesCollection[C any] struct {
-	edges map[reflect.Type][]*Transition[C]
-	count int
+	edges   map[reflect.Type][]*Transition[C]
+	dynamic []*Transition[C] // transitions whose matcher cannot be indexed by signal type
+	all     []*Transition[C] // every transition, in insertion order
+	count   int
 }
 
 // newEdgesCollection builds a new empty collection.
@@ -50,38 +90,76 @@ func (c *edgesCollection[C]) add(t *Transition[C]) {
 		c.edges = make(map[reflect.Type][]*Transition[C])
 	}
 
-	if _, ok := c.edges[reflect.TypeOf(t.signal)]; !ok {
-		c.edges[reflect.TypeOf(t.signal)] = make([]*Transition[C], 0)
+	c.count++
+	c.all = append(c.all, t)
+
+	var key reflect.Type
+
+	switch m := t.matcher.(type) {
+	case nil:
+		key = reflect.TypeOf(t.signal)
+	case indexedMatcher:
+		key = m.signalType()
+	default:
+		c.dynamic = insert(c.dynamic, t)
+
+		return
 	}
 
+	c.edges[key] = insert(c.edges[key], t)
+}
+
+// insert adds the given transition to the given list, guarded transitions are
+// prepended so they are evaluated before unguarded ones.
+func insert[C any](list []*Transition[C], t *Transition[C]) []*Transition[C] {
 	if t.guard == nil {
 		// APPEND
-		c.edges[reflect.TypeOf(t.signal)] = append(c.edges[reflect.TypeOf(t.signal)], t)
-	} else {
-		// PREPEND
-		c.edges[reflect.TypeOf(t.signal)] = append([]*Transition[C]{t}, c.edges[reflect.TypeOf(t.signal)]...)
+		return append(list, t)
 	}
 
-	c.count++
+	// PREPEND
+	return append([]*Transition[C]{t}, list...)
 }
 
-// list returns a plain list of transitions.
+// list returns a plain list of transitions, in insertion order.
 func (c *edgesCollection[C]) list() []*Transition[C] {
-	var transitions []*Transition[C]
-	for _, tg := range c.edges {
-		transitions = append(transitions, tg...)
-	}
-
-	return transitions
+	return c.all
 }
 
 // bySignal returns a plain list of transitions which signal matches the given one.
 func (c *edgesCollection[C]) bySignal(s interface{}) []*Transition[C] {
-	if transitions, ok := c.edges[reflect.TypeOf(s)]; ok {
-		return transitions
+	return c.edges[reflect.TypeOf(s)]
+}
+
+// clone returns a new collection holding the transitions returned by the given function
+// for each transition of this collection, preserving their order.
+func (c *edgesCollection[C]) clone(fn func(t *Transition[C]) *Transition[C]) *edgesCollection[C] {
+	var (
+		out    = newEdgesCollection[C]()
+		copies = make(map[*Transition[C]]*Transition[C], len(c.all))
+	)
+
+	for _, t := range c.all {
+		copies[t] = fn(t)
+		out.all = append(out.all, copies[t])
 	}
 
-	return make([]*Transition[C], 0)
+	for signal, transitions := range c.edges {
+		list := make([]*Transition[C], 0, len(transitions))
+		for _, t := range transitions {
+			list = append(list, copies[t])
+		}
+
+		out.edges[signal] = list
+	}
+
+	for _, t := range c.dynamic {
+		out.dynamic = append(out.dynamic, copies[t])
+	}
+
+	out.count = c.count
+
+	return out
 }
 
 // size returns how many transitions this collection is holding.
@@ -89,11 +167,52 @@ func (c *edgesCollection[C]) size() int {
 	return c.count
 }
 
+// lookup returns the transitions that may be triggered by the given signal from this
+// vertex, in dispatch order.
+func (n *Vertex[C]) lookup(signal Signal) []*Transition[C] {
+	if transitions, ok := n.table[reflect.TypeOf(signal)]; ok || signal == nil {
+		return transitions
+	}
+
+	return n.fallback
+}
+
 // ID returns vertex identity.
 func (n *Vertex[C]) ID() string {
 	return n.id
 }
 
+// Kind returns the kind of this vertex.
+func (n *Vertex[C]) Kind() VertexKind {
+	return n.kind
+}
+
+// Parent returns the parent state of this vertex, nil for top-level vertices.
+func (n *Vertex[C]) Parent() *Vertex[C] {
+	return n.parent
+}
+
+// Children returns the vertices having this vertex as parent, sorted by ID. Children are
+// only known by vertices obtained from a compiled machine, e.g. using `HSM.States()`.
+func (n *Vertex[C]) Children() []*Vertex[C] {
+	return append([]*Vertex[C](nil), n.children...)
+}
+
+// EntryState returns the entry state of this vertex, nil if it has none.
+func (n *Vertex[C]) EntryState() *Vertex[C] {
+	return n.entryState
+}
+
+// Transitions returns the outgoing transitions of this vertex, in the order they were
+// added. Inherited transitions are not included.
+func (n *Vertex[C]) Transitions() []*Transition[C] {
+	if n.edges == nil {
+		return nil
+	}
+
+	return append([]*Transition[C](nil), n.edges.list()...)
+}
+
 // Final indicates whether this vertex is a final state (has no outgoing transitions).
 func (n *Vertex[C]) Final() bool {
 	return n.edges.size() == 0
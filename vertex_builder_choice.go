@@ -42,7 +42,7 @@ func (b *choiceVertexBuilder[C]) AddTransitions(transitions ...*Transition[C]) C
 func (b *choiceVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:     b.id,
-		kind:   vertexKindChoice,
+		kind:   VertexKindChoice,
 		parent: b.parent,
 		edges:  b.edges,
 	}
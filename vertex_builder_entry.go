@@ -59,7 +59,7 @@ func (b *entryVertexBuilder[C]) AddTransitions(transitions ...*Transition[C]) En
 func (b *entryVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:      b.id,
-		kind:    vertexKindEntry,
+		kind:    VertexKindEntry,
 		parent:  b.parent,
 		onEntry: b.onEntry,
 		onExit:  b.onExit,
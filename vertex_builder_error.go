@@ -30,7 +30,7 @@ func (b *errorVertexBuilder[C]) OnEntry(action *Action[C]) ErrorVertexBuilder[C]
 func (b *errorVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:      b.id,
-		kind:    vertexKindError,
+		kind:    VertexKindError,
 		onEntry: b.onEntry,
 		edges:   newEdgesCollection[C](),
 	}
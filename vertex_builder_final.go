@@ -3,12 +3,14 @@ package hsm
 // FinalVertexBuilder builder.
 type FinalVertexBuilder[C any] interface {
 	WithID(id string) FinalVertexBuilder[C]
+	ParentOf(parent *Vertex[C]) FinalVertexBuilder[C]
 	OnEntry(action *Action[C]) FinalVertexBuilder[C]
 	Build() *Vertex[C]
 }
 
 type finalVertexBuilder[C any] struct {
 	id      string
+	parent  *Vertex[C]
 	onEntry *Action[C]
 }
 
@@ -19,6 +21,13 @@ func (b *finalVertexBuilder[C]) WithID(id string) FinalVertexBuilder[C] {
 	return b
 }
 
+// ParentOf indicates vertex's parent.
+func (b *finalVertexBuilder[C]) ParentOf(parent *Vertex[C]) FinalVertexBuilder[C] {
+	b.parent = parent
+
+	return b
+}
+
 // OnEntry defines vertex's entry action.
 func (b *finalVertexBuilder[C]) OnEntry(action *Action[C]) FinalVertexBuilder[C] {
 	b.onEntry = action
@@ -30,7 +39,8 @@ func (b *finalVertexBuilder[C]) OnEntry(action *Action[C]) FinalVertexBuilder[C]
 func (b *finalVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:      b.id,
-		kind:    vertexKindFinal,
+		kind:    VertexKindFinal,
+		parent:  b.parent,
 		onEntry: b.onEntry,
 		edges:   newEdgesCollection[C](),
 	}
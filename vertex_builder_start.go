@@ -42,7 +42,7 @@ func (b *startVertexBuilder[C]) AddTransitions(transitions ...*Transition[C]) St
 func (b *startVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:     b.id,
-		kind:   vertexKindStart,
+		kind:   VertexKindStart,
 		onExit: b.onExit,
 		edges:  b.edges,
 	}
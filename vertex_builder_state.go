@@ -69,7 +69,7 @@ func (b *stateVertexBuilder[C]) AddTransitions(transitions ...*Transition[C]) St
 func (b *stateVertexBuilder[C]) Build() *Vertex[C] {
 	vertex := &Vertex[C]{
 		id:         b.id,
-		kind:       vertexKindState,
+		kind:       VertexKindState,
 		parent:     b.parent,
 		entryState: b.entryState,
 		onEntry:    b.onEntry,
@@ -77,7 +77,14 @@ func (b *stateVertexBuilder[C]) Build() *Vertex[C] {
 		edges:      b.edges,
 	}
 
-	if vertex.entryState != nil {
+	if entry := vertex.entryState; entry != nil {
+		// compiled vertices are shared by every machine of their definition
+		if entry.compiled {
+			adopted := *entry
+			adopted.compiled = false
+			vertex.entryState = &adopted
+		}
+
 		vertex.entryState.parent = vertex
 	}
 